@@ -0,0 +1,206 @@
+package cooldown
+
+import (
+	"math"
+	"math/rand/v2"
+	"sync/atomic"
+	"time"
+)
+
+// BackoffConfig is the configuration of the Backoff cooldown.
+type BackoffConfig struct {
+	// Base is the cooldown duration on zero penalty level.
+	Base time.Duration
+	// Multiplier is the factor the duration is multiplied by on every penalty
+	// level. If it is less than 1, 2 is used.
+	Multiplier float64
+	// Max is the maximum duration of the cooldown. Zero means no limit.
+	Max time.Duration
+	// Jitter is the fraction of the duration it may be randomly changed by,
+	// e.g. 0.1 means ±10%. Zero disables jitter. It is clamped to [0, 1), so
+	// the duration is always positive.
+	Jitter float64
+	// Decay is the quiet period after which penalty level is decreased by
+	// one. Zero disables decay.
+	Decay time.Duration
+}
+
+// Backoff is the escalating penalty cooldown built on Valued. Every violation
+// (use while cooldown is active) increases the penalty level, and the next
+// cooldown becomes longer. After Decay without violations the level goes
+// back down.
+//
+// Decay is applied lazily, so HandleLevel for decay is called on the next
+// use of the Backoff. Level and Duration already account for it.
+type Backoff[T any] struct {
+	// All the state is controlled by the lock of the underlying Valued.
+	valued *Valued[T]
+
+	config BackoffConfig
+	level  int
+	// changed is the time of the last level change.
+	changed time.Time
+
+	handler atomic.Pointer[BackoffHandler[T]]
+}
+
+// NewBackoff creates new Backoff cooldown.
+func NewBackoff[T any](config BackoffConfig, opts ...BackoffOption[T]) *Backoff[T] {
+	if config.Multiplier < 1 {
+		config.Multiplier = 2
+	}
+	config.Jitter = min(max(config.Jitter, 0), math.Nextafter(1, 0))
+	cd := &Backoff[T]{valued: NewValued[T](), config: config}
+	for _, opt := range opts {
+		if opt == nil {
+			continue
+		}
+		opt(cd)
+	}
+	if cd.handler.Load() == nil {
+		h := BackoffHandler[T](NopBackoffHandler[T]{})
+		cd.handler.Store(&h)
+	}
+	return cd
+}
+
+// Use starts the cooldown with the duration of the current penalty level if
+// it is not active. Otherwise, it is counted as violation, penalty level is
+// increased and false is returned with duration until the cooldown expires.
+func (cooldown *Backoff[T]) Use(val T) (ok bool, remaining time.Duration) {
+	cooldown.valued.mu.Lock()
+	defer cooldown.valued.mu.Unlock()
+	return cooldown.UseUnsafe(val)
+}
+
+func (cooldown *Backoff[T]) UseUnsafe(val T) (ok bool, remaining time.Duration) {
+	now := cooldown.valued.basic.now()
+	cooldown.decayUnsafe(now, val)
+	if cooldown.valued.ActiveUnsafe() {
+		cooldown.setLevelUnsafe(cooldown.level+1, now, val)
+		return false, cooldown.valued.RemainingUnsafe()
+	}
+	if !cooldown.valued.StartUnsafe(cooldown.jitter(cooldown.durationUnsafe()), val) {
+		return false, 0
+	}
+	if cooldown.changed.IsZero() {
+		// Decay is counted from the first use.
+		cooldown.changed = now
+	}
+	return true, cooldown.valued.RemainingUnsafe()
+}
+
+// decayUnsafe decreases the penalty level for every Decay passed since the
+// last change.
+func (cooldown *Backoff[T]) decayUnsafe(now time.Time, val T) {
+	if level, changed := cooldown.decayedUnsafe(now); level != cooldown.level {
+		cooldown.setLevelUnsafe(level, changed, val)
+	}
+}
+
+// decayedUnsafe returns the penalty level at provided time and the time of
+// its last change, without changing them.
+func (cooldown *Backoff[T]) decayedUnsafe(now time.Time) (level int, changed time.Time) {
+	decay := cooldown.config.Decay
+	if decay <= 0 || cooldown.level == 0 {
+		return cooldown.level, cooldown.changed
+	}
+	steps := int(now.Sub(cooldown.changed) / decay)
+	if steps <= 0 {
+		return cooldown.level, cooldown.changed
+	}
+	return max(cooldown.level-steps, 0), cooldown.changed.Add(time.Duration(steps) * decay)
+}
+
+func (cooldown *Backoff[T]) setLevelUnsafe(level int, changed time.Time, val T) {
+	from := cooldown.level
+	cooldown.level, cooldown.changed = level, changed
+	if from != level {
+		cooldown.Handler().HandleLevel(cooldown, from, level, val)
+	}
+}
+
+// durationUnsafe returns cooldown duration of the current level without
+// jitter.
+func (cooldown *Backoff[T]) durationUnsafe() time.Duration {
+	return cooldown.durationAt(cooldown.level)
+}
+
+// durationAt returns cooldown duration of provided level without jitter.
+func (cooldown *Backoff[T]) durationAt(level int) time.Duration {
+	c := cooldown.config
+	d := float64(c.Base) * math.Pow(c.Multiplier, float64(level))
+	if c.Max > 0 && d > float64(c.Max) {
+		return c.Max
+	}
+	if d > math.MaxInt64 {
+		return time.Duration(math.MaxInt64)
+	}
+	return time.Duration(d)
+}
+
+// jitter randomly changes the duration by the configured fraction.
+func (cooldown *Backoff[T]) jitter(d time.Duration) time.Duration {
+	c := cooldown.config
+	if c.Jitter <= 0 {
+		return d
+	}
+	d = time.Duration(float64(d) * (1 + c.Jitter*(2*rand.Float64()-1)))
+	if c.Max > 0 {
+		d = min(d, c.Max)
+	}
+	// Duration may be rounded down to zero for the jitter close to 1.
+	return max(d, 1)
+}
+
+// Level returns the current penalty level.
+func (cooldown *Backoff[T]) Level() int {
+	cooldown.valued.mu.RLock()
+	defer cooldown.valued.mu.RUnlock()
+	level, _ := cooldown.decayedUnsafe(cooldown.valued.basic.now())
+	return level
+}
+
+// Duration returns duration of the next cooldown without jitter.
+func (cooldown *Backoff[T]) Duration() time.Duration {
+	cooldown.valued.mu.RLock()
+	defer cooldown.valued.mu.RUnlock()
+	level, _ := cooldown.decayedUnsafe(cooldown.valued.basic.now())
+	return cooldown.durationAt(level)
+}
+
+// Reset stops the cooldown and resets the penalty level.
+func (cooldown *Backoff[T]) Reset(val T) {
+	cooldown.valued.mu.Lock()
+	defer cooldown.valued.mu.Unlock()
+	cooldown.valued.StopUnsafe(val)
+	cooldown.setLevelUnsafe(0, time.Time{}, val)
+}
+
+// Active ...
+func (cooldown *Backoff[T]) Active() bool {
+	return cooldown.valued.Active()
+}
+
+// Remaining ...
+func (cooldown *Backoff[T]) Remaining() time.Duration {
+	return cooldown.valued.Remaining()
+}
+
+// Handler ...
+func (cooldown *Backoff[T]) Handler() BackoffHandler[T] {
+	return *cooldown.handler.Load()
+}
+
+// Handle ...
+func (cooldown *Backoff[T]) Handle(handler BackoffHandler[T]) {
+	if handler == nil {
+		handler = NopBackoffHandler[T]{}
+	}
+	cooldown.handler.Store(&handler)
+}
+
+// Valued returns the underlying Valued cooldown.
+func (cooldown *Backoff[T]) Valued() *Valued[T] {
+	return cooldown.valued
+}
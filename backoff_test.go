@@ -0,0 +1,68 @@
+package cooldown_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+	"github.com/k4ties/cooldown"
+)
+
+type levelHandler struct {
+	cooldown.NopBackoffHandler[string]
+	levels []int
+}
+
+func (h *levelHandler) HandleLevel(_ *cooldown.Backoff[string], _, to int, _ string) {
+	h.levels = append(h.levels, to)
+}
+
+func TestBackoff(t *testing.T) {
+	h, clock := new(levelHandler), newFakeClock()
+	b := cooldown.NewBackoff(cooldown.BackoffConfig{
+		Base:  time.Millisecond * 10,
+		Max:   time.Millisecond * 30,
+		Decay: time.Millisecond * 100,
+	}, cooldown.BackoffOptionHandler[string](h), cooldown.BackoffOptionClock[string](clock))
+
+	ok, _ := b.Use("login")
+	assert.Equal(t, ok, true)
+	for range 3 {
+		ok, _ = b.Use("login")
+		assert.Equal(t, ok, false)
+	}
+	assert.Equal(t, b.Level(), 3)
+	// Duration is limited by Max
+	assert.Equal(t, b.Duration(), time.Millisecond*30)
+	assert.Equal(t, h.levels, []int{1, 2, 3})
+
+	clock.Advance(b.Remaining())
+	ok, remaining := b.Use("login")
+	assert.Equal(t, ok, true)
+	assert.Equal(t, remaining, time.Millisecond*30)
+
+	// Penalty decays after quiet period. Accessors don't call the handler,
+	// it is called on the next use.
+	clock.Advance(time.Millisecond * 210)
+	assert.Equal(t, b.Level(), 1)
+	assert.Equal(t, b.Duration(), time.Millisecond*20)
+	assert.Equal(t, h.levels, []int{1, 2, 3})
+	ok, _ = b.Use("login")
+	assert.Equal(t, ok, true)
+	assert.Equal(t, h.levels, []int{1, 2, 3, 1})
+	b.Reset("login")
+	assert.Equal(t, b.Level(), 0)
+	assert.Equal(t, h.levels, []int{1, 2, 3, 1, 0})
+}
+
+func TestBackoffJitter(t *testing.T) {
+	b := cooldown.NewBackoff(cooldown.BackoffConfig{Base: time.Millisecond, Jitter: 5},
+		cooldown.BackoffOptionClock[string](newFakeClock()))
+	// Jitter is clamped, so the duration is never cut down to zero.
+	for range 100 {
+		ok, remaining := b.Use("login")
+		assert.Equal(t, ok, true)
+		assert.Equal(t, remaining > 0 && remaining < time.Millisecond*2, true)
+		b.Reset("login")
+	}
+}
@@ -13,6 +13,34 @@ type Basic struct {
 	expiration,
 	// pausedAt is time when cooldown was paused.
 	pausedAt time.Time
+	// clock is the clock of the cooldown. If it is nil, SystemClock is used.
+	clock Clock
+}
+
+// NewBasic creates new Basic cooldown. It is needed only to provide the
+// options, otherwise new(cooldown.Basic) can be used.
+func NewBasic(opts ...BasicOption) *Basic {
+	cd := new(Basic)
+	for _, opt := range opts {
+		if opt == nil {
+			continue
+		}
+		opt(cd)
+	}
+	return cd
+}
+
+// clockOrSystem returns the clock of the cooldown.
+func (cooldown *Basic) clockOrSystem() Clock {
+	if cooldown.clock == nil {
+		return SystemClock{}
+	}
+	return cooldown.clock
+}
+
+// now returns the current time on the cooldown clock.
+func (cooldown *Basic) now() time.Time {
+	return cooldown.clockOrSystem().Now()
 }
 
 // Set updates state of the cooldown.
@@ -28,7 +56,27 @@ func (cooldown *Basic) SetUnsafe(dur time.Duration) {
 	if dur <= 0 {
 		return
 	}
-	cooldown.expiration = time.Now().Add(dur)
+	cooldown.expiration = cooldown.now().Add(dur)
+}
+
+// TryStart sets the cooldown only if it is not active, checking and setting
+// it under single lock acquisition. Remaining is the duration until the
+// cooldown expires, whether it was started or not.
+func (cooldown *Basic) TryStart(dur time.Duration) (started bool, remaining time.Duration) {
+	cooldown.L.Lock()
+	defer cooldown.L.Unlock()
+	return cooldown.TryStartUnsafe(dur)
+}
+
+func (cooldown *Basic) TryStartUnsafe(dur time.Duration) (started bool, remaining time.Duration) {
+	if cooldown.ActiveUnsafe() {
+		return false, cooldown.RemainingUnsafe()
+	}
+	if dur <= 0 {
+		return false, 0
+	}
+	cooldown.SetUnsafe(dur)
+	return true, cooldown.RemainingUnsafe()
 }
 
 // Pause pauses cooldown if it is not already paused.
@@ -44,7 +92,7 @@ func (cooldown *Basic) PauseUnsafe() bool {
 	if !state.Active || state.Paused {
 		return false
 	}
-	cooldown.pausedAt = time.Now()
+	cooldown.pausedAt = cooldown.now()
 	return true
 }
 
@@ -65,6 +113,19 @@ func (cooldown *Basic) ResumeUnsafe() bool {
 	return true
 }
 
+// resumeShiftedUnsafe works like ResumeUnsafe, but time spent in pause is not
+// counted, so the cooldown has the same remaining duration it was paused
+// with. It is used by the Valued cooldown, which timer is stopped in pause.
+func (cooldown *Basic) resumeShiftedUnsafe() bool {
+	pausedAt, ok := cooldown.pausedDateUnsafe()
+	if !ok {
+		return false
+	}
+	cooldown.expiration = cooldown.expiration.Add(cooldown.now().Sub(pausedAt))
+	cooldown.pausedAt = time.Time{}
+	return true
+}
+
 // TogglePause toggles the pause state of the cooldown.
 // Returns true if cooldown was paused.
 func (cooldown *Basic) TogglePause() (paused bool) {
@@ -130,7 +191,7 @@ func (cooldown *Basic) RemainingUnsafe() time.Duration {
 		return res.Expiration.Sub(res.PausedDate)
 	}
 	// Note: Expiration can't be zero here
-	return time.Until(res.Expiration)
+	return res.Expiration.Sub(cooldown.now())
 }
 
 func (cooldown *Basic) pausedDateUnsafe() (_ time.Time, _ bool) {
@@ -159,16 +220,21 @@ func (cooldown *Basic) State() (res BasicState) {
 }
 
 func (cooldown *Basic) StateUnsafe() (state BasicState) {
-	if pausedDate, ok := cooldown.pausedDateUnsafe(); ok {
+	return basicStateAt(cooldown.expiration, cooldown.pausedAt, cooldown.now())
+}
+
+// basicStateAt returns state of the cooldown with provided expiration and
+// pause date at provided time.
+func basicStateAt(expiration, pausedAt, now time.Time) (state BasicState) {
+	if !pausedAt.IsZero() {
 		state.Paused = true
-		state.PausedDate = pausedDate
+		state.PausedDate = pausedAt
 	}
-	expiration := cooldown.expiration
 	if expiration.IsZero() {
 		return
 	}
 	state.Expiration = expiration
-	reference := time.Now()
+	reference := now
 	if state.Paused {
 		reference = state.PausedDate
 	}
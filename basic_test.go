@@ -161,3 +161,52 @@ func testCoolDownBasically[T anyCoolDown](b T, props cooldownProperties[T]) func
 		})
 	}
 }
+
+func TestBasicTryStart(t *testing.T) {
+	b := new(cooldown.Basic)
+	started, remaining := b.TryStart(time.Second)
+	assert.Equal(t, started, true)
+	assert.Equal(t, remaining > 0, true)
+
+	started, remaining = b.TryStart(time.Second)
+	assert.Equal(t, started, false)
+	assert.Equal(t, remaining > 0 && remaining <= time.Second, true)
+}
+
+func TestBasicTolerance(t *testing.T) {
+	b := new(cooldown.Basic)
+	b.Set(time.Millisecond * 20)
+	assert.Equal(t, b.ReadyWithTolerance(time.Millisecond), false)
+	assert.Equal(t, b.ReadyWithTolerance(time.Millisecond*50), true)
+
+	started, early := b.TryStartWithTolerance(time.Second, time.Millisecond*50)
+	assert.Equal(t, started, true)
+	assert.Equal(t, early > 0, true)
+
+	started, _ = b.TryStartWithTolerance(time.Second, time.Millisecond*50)
+	assert.Equal(t, started, false)
+}
+
+func TestBasicResume(t *testing.T) {
+	b := new(cooldown.Basic)
+	b.Set(time.Millisecond * 50)
+	assert.Equal(t, b.Pause(), true)
+	paused := b.Remaining()
+	<-time.After(time.Millisecond * 80)
+	assert.Equal(t, b.Resume(), true)
+	// Time spent in pause is counted, so the cooldown has expired.
+	assert.Equal(t, paused > 0, true)
+	assert.Equal(t, b.Active(), false)
+}
+
+func TestValuedResume(t *testing.T) {
+	clock := newFakeClock()
+	cd := cooldown.NewValued[int](cooldown.ValuedOptionClock[int](clock))
+	cd.Start(time.Millisecond*50, 0)
+	assert.Equal(t, cd.Pause(0), true)
+	clock.Advance(time.Millisecond * 80)
+	assert.Equal(t, cd.Resume(0), true)
+	// Time spent in pause is not counted, so the cooldown hasn't expired.
+	assert.Equal(t, cd.Active(), true)
+	assert.Equal(t, cd.Remaining(), time.Millisecond*50)
+}
@@ -0,0 +1,387 @@
+package cooldown
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// Limit is the rate of the Bucket refill, in tokens per second.
+type Limit float64
+
+// Inf is the infinite rate limit, it allows all the events.
+const Inf = Limit(math.MaxFloat64)
+
+// infDuration is the duration returned when tokens will never be available.
+const infDuration = time.Duration(math.MaxInt64)
+
+// Every converts minimum interval between events to Limit.
+func Every(interval time.Duration) Limit {
+	if interval <= 0 {
+		return Inf
+	}
+	return 1 / Limit(interval.Seconds())
+}
+
+// durationFromTokens returns duration required to refill provided tokens.
+func (limit Limit) durationFromTokens(tokens float64) time.Duration {
+	if limit <= 0 {
+		return infDuration
+	}
+	d := tokens / float64(limit) * float64(time.Second)
+	if d >= float64(infDuration) {
+		return infDuration
+	}
+	return time.Duration(d)
+}
+
+// tokensFromDuration returns count of tokens refilled in provided duration.
+func (limit Limit) tokensFromDuration(d time.Duration) float64 {
+	if limit <= 0 {
+		return 0
+	}
+	return d.Seconds() * float64(limit)
+}
+
+// Bucket is the token bucket limiter. It has the same shape as Limiter from
+// golang.org/x/time/rate: it is refilled with limit tokens per second up to
+// burst tokens, and every event takes one token.
+//
+// Like Basic, the bucket can be paused: while it is paused it isn't refilled.
+// All times passed to the methods are on the bucket Clock.
+type Bucket struct {
+	mu sync.Mutex
+
+	limit  Limit
+	burst  int
+	tokens float64
+	// last is the last time tokens were updated, on the bucket time.
+	last time.Time
+
+	clock    Clock
+	pausedAt time.Time
+	// shift is the total duration bucket was paused for.
+	shift time.Duration
+}
+
+// NewBucket creates new Bucket with provided rate and burst. Bucket is
+// initially full.
+func NewBucket(limit Limit, burst int, opts ...BucketOption) *Bucket {
+	b := &Bucket{limit: limit, burst: burst, tokens: float64(burst), clock: SystemClock{}}
+	for _, opt := range opts {
+		if opt == nil {
+			continue
+		}
+		opt(b)
+	}
+	return b
+}
+
+// Allow is shorthand for AllowN(clock.Now(), 1).
+func (b *Bucket) Allow() bool {
+	return b.AllowN(b.clock.Now(), 1)
+}
+
+// AllowN reports whether n events may happen at time t, taking the tokens if
+// they may.
+func (b *Bucket) AllowN(t time.Time, n int) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.reserveNUnsafe(t, n, 0).ok
+}
+
+// Reserve is shorthand for ReserveN(clock.Now(), 1).
+func (b *Bucket) Reserve() *Reservation {
+	return b.ReserveN(b.clock.Now(), 1)
+}
+
+// ReserveN returns Reservation that indicates how long the caller must wait
+// before n events happen. Tokens are taken immediately, use Reservation.Cancel
+// to return them if the events won't happen.
+func (b *Bucket) ReserveN(t time.Time, n int) *Reservation {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.reserveNUnsafe(t, n, infDuration)
+}
+
+// Wait is shorthand for WaitN(ctx, 1).
+func (b *Bucket) Wait(ctx context.Context) error {
+	return b.WaitN(ctx, 1)
+}
+
+// WaitN blocks until n events may happen. It returns error if n exceeds the
+// burst, the context is cancelled, or its deadline is earlier than the time
+// the events may happen.
+func (b *Bucket) WaitN(ctx context.Context, n int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	now := b.clock.Now()
+	wait := infDuration
+	if deadline, ok := ctx.Deadline(); ok {
+		wait = deadline.Sub(now)
+	}
+
+	b.mu.Lock()
+	if b.limit != Inf && n > b.burst {
+		b.mu.Unlock()
+		return ErrExceedsBurst
+	}
+	r := b.reserveNUnsafe(now, n, wait)
+	b.mu.Unlock()
+	if !r.ok {
+		return ErrExceedsDeadline
+	}
+
+	delay := r.DelayFrom(now)
+	if delay == 0 {
+		return nil
+	}
+	ready := make(chan struct{})
+	timer := b.clock.AfterFunc(delay, func() {
+		close(ready)
+	})
+	defer timer.Stop()
+	select {
+	case <-ready:
+		return nil
+	case <-ctx.Done():
+		r.Cancel()
+		return ctx.Err()
+	}
+}
+
+// reserveNUnsafe takes n tokens if they will be available within maxWait.
+func (b *Bucket) reserveNUnsafe(t time.Time, n int, maxWait time.Duration) *Reservation {
+	if b.limit == Inf {
+		return &Reservation{ok: true, bucket: b, tokens: n, timeToAct: t}
+	}
+	bt := b.bucketTimeUnsafe(t)
+	tokens := b.advanceUnsafe(bt) - float64(n)
+
+	var wait time.Duration
+	if tokens < 0 {
+		wait = b.limit.durationFromTokens(-tokens)
+	}
+	// Tokens are never refilled if the wait is infinite, e.g. with zero limit.
+	r := &Reservation{bucket: b, ok: n <= b.burst && wait <= maxWait && wait < infDuration}
+	if !r.ok {
+		return r
+	}
+	r.tokens, r.timeToAct = n, t.Add(wait)
+	b.tokens, b.last = tokens, bt
+	return r
+}
+
+// advanceUnsafe returns count of tokens at provided bucket time.
+func (b *Bucket) advanceUnsafe(bt time.Time) float64 {
+	last := b.last
+	if bt.Before(last) {
+		last = bt
+	}
+	tokens := b.tokens + b.limit.tokensFromDuration(bt.Sub(last))
+	return min(tokens, float64(b.burst))
+}
+
+// bucketTimeUnsafe converts clock time to the bucket time, which doesn't go
+// while the bucket is paused.
+func (b *Bucket) bucketTimeUnsafe(t time.Time) time.Time {
+	if !b.pausedAt.IsZero() && t.After(b.pausedAt) {
+		t = b.pausedAt
+	}
+	return t.Add(-b.shift)
+}
+
+// Tokens returns count of tokens available now.
+func (b *Bucket) Tokens() float64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.advanceUnsafe(b.bucketTimeUnsafe(b.clock.Now()))
+}
+
+// Limit returns the refill rate.
+func (b *Bucket) Limit() Limit {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.limit
+}
+
+// SetLimit changes the refill rate. Tokens refilled before the change are
+// kept.
+func (b *Bucket) SetLimit(limit Limit) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	bt := b.bucketTimeUnsafe(b.clock.Now())
+	b.tokens, b.last = b.advanceUnsafe(bt), bt
+	b.limit = limit
+}
+
+// Burst returns the maximum count of tokens.
+func (b *Bucket) Burst() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.burst
+}
+
+// SetBurst changes the maximum count of tokens.
+func (b *Bucket) SetBurst(burst int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	bt := b.bucketTimeUnsafe(b.clock.Now())
+	b.tokens, b.last = b.advanceUnsafe(bt), bt
+	b.burst = burst
+	b.tokens = min(b.tokens, float64(burst))
+}
+
+// Pause pauses refilling of the bucket. Returns true if it was paused.
+func (b *Bucket) Pause() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.pausedAt.IsZero() {
+		return false
+	}
+	b.pausedAt = b.clock.Now()
+	return true
+}
+
+// Resume resumes refilling of the bucket. Returns true if it was resumed.
+func (b *Bucket) Resume() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.pausedAt.IsZero() {
+		return false
+	}
+	b.shift += b.clock.Now().Sub(b.pausedAt)
+	b.pausedAt = time.Time{}
+	return true
+}
+
+// Paused returns true if bucket is paused.
+func (b *Bucket) Paused() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return !b.pausedAt.IsZero()
+}
+
+// Reservation holds tokens taken by the Bucket for events that may happen in
+// the future.
+type Reservation struct {
+	ok        bool
+	bucket    *Bucket
+	tokens    int
+	timeToAct time.Time
+}
+
+// OK returns whether the bucket can provide the tokens. If it is false,
+// Delay returns infinite duration and Cancel does nothing.
+func (r *Reservation) OK() bool {
+	return r.ok
+}
+
+// Delay is shorthand for DelayFrom(clock.Now()).
+func (r *Reservation) Delay() time.Duration {
+	return r.DelayFrom(r.bucket.clock.Now())
+}
+
+// DelayFrom returns duration the caller must wait before the events may
+// happen.
+func (r *Reservation) DelayFrom(t time.Time) time.Duration {
+	if !r.ok {
+		return infDuration
+	}
+	return max(r.timeToAct.Sub(t), 0)
+}
+
+// Cancel returns reserved tokens to the bucket. Unlike x/time/rate, tokens
+// reserved after this reservation are not taken into account, so the bucket
+// is just refilled with the reserved tokens.
+func (r *Reservation) Cancel() {
+	if !r.ok || r.tokens == 0 {
+		return
+	}
+	b := r.bucket
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.limit == Inf || !r.timeToAct.After(b.clock.Now()) {
+		// Events have already happened.
+		return
+	}
+	bt := b.bucketTimeUnsafe(b.clock.Now())
+	b.tokens, b.last = b.advanceUnsafe(bt), bt
+	b.tokens = min(b.tokens+float64(r.tokens), float64(b.burst))
+	r.tokens = 0
+}
+
+// KeyedBucket is keyed collection of token buckets, e.g. per IP address.
+// Buckets are created lazily with provided function on first use of the key.
+type KeyedBucket[K comparable] struct {
+	mu sync.RWMutex
+
+	buckets map[K]*Bucket
+	create  func(key K) *Bucket
+}
+
+// NewKeyedBucket creates new KeyedBucket.
+func NewKeyedBucket[K comparable](create func(key K) *Bucket) *KeyedBucket[K] {
+	return &KeyedBucket[K]{buckets: make(map[K]*Bucket), create: create}
+}
+
+// Get returns bucket with provided key, creating it if it doesn't exist.
+func (k *KeyedBucket[K]) Get(key K) *Bucket {
+	k.mu.RLock()
+	b, ok := k.buckets[key]
+	k.mu.RUnlock()
+	if ok {
+		return b
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if b, ok = k.buckets[key]; !ok {
+		b = k.create(key)
+		k.buckets[key] = b
+	}
+	return b
+}
+
+// Allow reports whether an event of the key may happen now.
+func (k *KeyedBucket[K]) Allow(key K) bool {
+	return k.Get(key).Allow()
+}
+
+// Reserve reserves a token of the key.
+func (k *KeyedBucket[K]) Reserve(key K) *Reservation {
+	return k.Get(key).Reserve()
+}
+
+// Wait blocks until an event of the key may happen.
+func (k *KeyedBucket[K]) Wait(ctx context.Context, key K) error {
+	return k.Get(key).Wait(ctx)
+}
+
+// Delete removes bucket with provided key.
+func (k *KeyedBucket[K]) Delete(key K) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	delete(k.buckets, key)
+}
+
+// Prune removes buckets that are full and aren't paused, so they are
+// indistinguishable from the new ones.
+func (k *KeyedBucket[K]) Prune() {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	for key, b := range k.buckets {
+		if !b.Paused() && b.Tokens() >= float64(b.Burst()) {
+			delete(k.buckets, key)
+		}
+	}
+}
+
+// Len returns count of buckets.
+func (k *KeyedBucket[K]) Len() int {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return len(k.buckets)
+}
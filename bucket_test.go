@@ -0,0 +1,176 @@
+package cooldown_test
+
+import (
+	"context"
+	"math"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+	"github.com/k4ties/cooldown"
+)
+
+// fakeClock is cooldown.Clock that goes only when it is advanced. Its timers
+// are fired by Advance, in the order of their deadlines.
+type fakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*fakeTimer
+}
+
+type fakeTimer struct {
+	clock *fakeClock
+	when  time.Time
+	f     func()
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) AfterFunc(d time.Duration, f func()) cooldown.ClockTimer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t := &fakeTimer{clock: c, when: c.now.Add(d), f: f}
+	c.timers = append(c.timers, t)
+	return t
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	target := c.now.Add(d)
+	for {
+		i := slices.IndexFunc(c.timers, func(t *fakeTimer) bool {
+			return !t.when.After(target)
+		})
+		if i < 0 {
+			break
+		}
+		for j, t := range c.timers {
+			if t.when.Before(c.timers[i].when) {
+				i = j
+			}
+		}
+		t := c.timers[i]
+		c.timers = slices.Delete(c.timers, i, i+1)
+		c.now = t.when
+		// Timers may lock the cooldowns, which may call the clock.
+		c.mu.Unlock()
+		t.f()
+		c.mu.Lock()
+	}
+	c.now = target
+	c.mu.Unlock()
+}
+
+func (t *fakeTimer) Stop() bool {
+	c := t.clock
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	i := slices.Index(c.timers, t)
+	if i < 0 {
+		return false
+	}
+	c.timers = slices.Delete(c.timers, i, i+1)
+	return true
+}
+
+func TestBucket(t *testing.T) {
+	clock := newFakeClock()
+	b := cooldown.NewBucket(cooldown.Every(time.Second), 2, cooldown.BucketOptionClock(clock))
+
+	assert.Equal(t, b.Allow(), true)
+	assert.Equal(t, b.Allow(), true)
+	assert.Equal(t, b.Allow(), false)
+	// More tokens than the burst are never allowed
+	assert.Equal(t, b.AllowN(clock.Now(), 3), false)
+
+	clock.Advance(time.Second)
+	assert.Equal(t, b.Tokens(), float64(1))
+	assert.Equal(t, b.Allow(), true)
+
+	r := b.Reserve()
+	assert.Equal(t, r.OK(), true)
+	assert.Equal(t, r.Delay(), time.Second)
+	r.Cancel()
+	assert.Equal(t, b.Tokens(), float64(0))
+
+	clock.Advance(time.Second * 10)
+	assert.Equal(t, b.Tokens(), float64(2))
+}
+
+func TestBucketPause(t *testing.T) {
+	clock := newFakeClock()
+	b := cooldown.NewBucket(cooldown.Every(time.Second), 1, cooldown.BucketOptionClock(clock))
+
+	assert.Equal(t, b.Allow(), true)
+	assert.Equal(t, b.Pause(), true)
+	clock.Advance(time.Second * 5)
+	// Bucket isn't refilled while it is paused
+	assert.Equal(t, b.Allow(), false)
+	assert.Equal(t, b.Resume(), true)
+	assert.Equal(t, b.Allow(), false)
+	clock.Advance(time.Second)
+	assert.Equal(t, b.Allow(), true)
+}
+
+func TestBucketWait(t *testing.T) {
+	b := cooldown.NewBucket(cooldown.Every(time.Millisecond*10), 1)
+	assert.Equal(t, b.Wait(context.Background()), nil)
+	assert.Equal(t, b.Wait(context.Background()), nil)
+	assert.Equal(t, b.WaitN(context.Background(), 2), cooldown.ErrExceedsBurst)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	assert.Equal(t, b.Wait(ctx), cooldown.ErrExceedsDeadline)
+
+	// Wait returns when the time passes on the bucket clock.
+	clock := newFakeClock()
+	b = cooldown.NewBucket(cooldown.Every(time.Hour), 1, cooldown.BucketOptionClock(clock))
+	assert.Equal(t, b.Wait(context.Background()), nil)
+	done := make(chan error)
+	go func() {
+		done <- b.Wait(context.Background())
+	}()
+	for waited := time.Duration(0); ; waited += time.Minute {
+		select {
+		case err := <-done:
+			assert.Equal(t, err, nil)
+			assert.Equal(t, waited >= time.Hour, true)
+			return
+		case <-time.After(time.Millisecond):
+			clock.Advance(time.Minute)
+		}
+	}
+}
+
+func TestBucketZeroLimit(t *testing.T) {
+	clock := newFakeClock()
+	b := cooldown.NewBucket(0, 1, cooldown.BucketOptionClock(clock))
+	assert.Equal(t, b.Allow(), true)
+	// Tokens are never refilled.
+	r := b.Reserve()
+	assert.Equal(t, r.OK(), false)
+	assert.Equal(t, r.Delay(), time.Duration(math.MaxInt64))
+	assert.Equal(t, b.Wait(context.Background()), cooldown.ErrExceedsDeadline)
+	clock.Advance(time.Hour)
+	assert.Equal(t, b.Allow(), false)
+}
+
+func TestKeyedBucket(t *testing.T) {
+	k := cooldown.NewKeyedBucket(func(string) *cooldown.Bucket {
+		return cooldown.NewBucket(cooldown.Every(time.Second), 1)
+	})
+	assert.Equal(t, k.Allow("127.0.0.1"), true)
+	assert.Equal(t, k.Allow("127.0.0.1"), false)
+	assert.Equal(t, k.Allow("127.0.0.2"), true)
+	assert.Equal(t, k.Len(), 2)
+}
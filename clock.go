@@ -0,0 +1,33 @@
+package cooldown
+
+import "time"
+
+// Clock is the source of the current time for the cooldowns and limiters. It
+// can be replaced with fake implementation in tests.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+	// AfterFunc waits for the duration to pass on the clock and then calls f
+	// in its own goroutine.
+	AfterFunc(d time.Duration, f func()) ClockTimer
+}
+
+// ClockTimer is the timer created by Clock.AfterFunc.
+type ClockTimer interface {
+	// Stop prevents the timer from firing. Returns false if the timer has
+	// already fired or been stopped.
+	Stop() bool
+}
+
+// SystemClock is the Clock using time.Now and time.AfterFunc.
+type SystemClock struct{}
+
+// Now ...
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+// AfterFunc ...
+func (SystemClock) AfterFunc(d time.Duration, f func()) ClockTimer {
+	return time.AfterFunc(d, f)
+}
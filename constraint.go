@@ -0,0 +1,131 @@
+package cooldown
+
+import (
+	"fmt"
+	"time"
+)
+
+// Constraint is the rule checked by Registry before starting the cooldown
+// with provided key. It returns non-nil error if the start must be rejected.
+//
+// Constraint is called while Registry is locked, so it must use only Unsafe
+// methods of the registry. Cooldowns returned by them aren't locked.
+type Constraint[K comparable, T any] func(reg *Registry[K, T], key K, val T) error
+
+// ConstraintReason identifies the rule that rejected the start.
+type ConstraintReason uint8
+
+const (
+	// ReasonExcluded means that the other cooldown is active.
+	ReasonExcluded ConstraintReason = iota + 1
+	// ReasonRequired means that the other cooldown hasn't expired recently
+	// enough.
+	ReasonRequired
+)
+
+// String ...
+func (r ConstraintReason) String() string {
+	switch r {
+	case ReasonExcluded:
+		return "excluded"
+	case ReasonRequired:
+		return "required"
+	default:
+		return "unknown"
+	}
+}
+
+// ConstraintError is the structured reason of rejected start.
+type ConstraintError[K comparable] struct {
+	// Key is the key of the cooldown that was rejected.
+	Key K
+	// Other is the key of the cooldown the constraint depends on.
+	Other K
+	// Reason is the rule that rejected the start.
+	Reason ConstraintReason
+	// Remaining is the duration until the constraint may pass. It is zero if
+	// it is unknown, e.g. when other cooldown must be started first.
+	Remaining time.Duration
+}
+
+// Error ...
+func (err *ConstraintError[K]) Error() string {
+	switch err.Reason {
+	case ReasonExcluded:
+		return fmt.Sprintf("cooldown %v can't be started while %v is active", err.Key, err.Other)
+	case ReasonRequired:
+		return fmt.Sprintf("cooldown %v requires %v to expire recently", err.Key, err.Other)
+	default:
+		return fmt.Sprintf("cooldown %v rejected by constraint", err.Key)
+	}
+}
+
+// Excludes returns constraint that rejects start of the cooldown with
+// provided key while the other cooldown is active. Cooldown can't exclude
+// itself, such constraint always passes.
+func Excludes[K comparable, T any](key, other K) Constraint[K, T] {
+	return func(reg *Registry[K, T], k K, _ T) error {
+		if k != key || other == key {
+			return nil
+		}
+		cd, ok := reg.LookupUnsafe(other)
+		if !ok {
+			return nil
+		}
+		cd.mu.RLock()
+		defer cd.mu.RUnlock()
+		if !cd.ActiveUnsafe() {
+			return nil
+		}
+		return &ConstraintError[K]{Key: key, Other: other, Reason: ReasonExcluded, Remaining: cd.RemainingUnsafe()}
+	}
+}
+
+// Exclusive returns constraint that makes cooldowns with provided keys
+// mutually exclusive: none of them can be started while another one is
+// active.
+func Exclusive[K comparable, T any](keys ...K) Constraint[K, T] {
+	return func(reg *Registry[K, T], k K, val T) error {
+		for _, key := range keys {
+			if key != k {
+				continue
+			}
+			for _, other := range keys {
+				if other == k {
+					continue
+				}
+				if err := Excludes[K, T](k, other)(reg, k, val); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+}
+
+// Requires returns constraint that allows to start cooldown with provided key
+// only if the other cooldown has expired within provided duration. Cooldown
+// can't require itself, such constraint always passes.
+func Requires[K comparable, T any](key, other K, within time.Duration) Constraint[K, T] {
+	return func(reg *Registry[K, T], k K, _ T) error {
+		if k != key || other == key {
+			return nil
+		}
+		err := &ConstraintError[K]{Key: key, Other: other, Reason: ReasonRequired}
+		cd, ok := reg.LookupUnsafe(other)
+		if !ok {
+			return err
+		}
+		cd.mu.RLock()
+		defer cd.mu.RUnlock()
+		if cd.ActiveUnsafe() {
+			err.Remaining = cd.RemainingUnsafe()
+			return err
+		}
+		expiredAt := cd.ExpiredAtUnsafe()
+		if expiredAt.IsZero() || cd.basic.now().Sub(expiredAt) > within {
+			return err
+		}
+		return nil
+	}
+}
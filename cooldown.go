@@ -46,6 +46,15 @@ func (cooldown *CoolDown) StartUnsafe(dur time.Duration) {
 	cooldown.valued.StartUnsafe(dur, zeroStruct)
 }
 
+// TryStart ...
+func (cooldown *CoolDown) TryStart(dur time.Duration) (started bool, remaining time.Duration) {
+	return cooldown.valued.TryStart(dur, zeroStruct)
+}
+
+func (cooldown *CoolDown) TryStartUnsafe(dur time.Duration) (started bool, remaining time.Duration) {
+	return cooldown.valued.TryStartUnsafe(dur, zeroStruct)
+}
+
 // Stop ...
 func (cooldown *CoolDown) Stop() {
 	cooldown.valued.Stop(zeroStruct)
@@ -1,6 +1,10 @@
 package cooldown
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"time"
+)
 
 // StopCause is used to identify reason of cooldown stop.
 type StopCause error
@@ -10,4 +14,36 @@ var (
 	ErrStopCauseExpired = errors.New("cooldown expired")
 	// ErrStopCauseCancelled used when cooldown is canceled in event by user.
 	ErrStopCauseCancelled = errors.New("cooldown cancelled")
+	// ErrStopCauseInterrupted used when Phased cooldown is interrupted in a
+	// phase that refunds the following phases.
+	ErrStopCauseInterrupted = errors.New("cooldown interrupted")
+	// ErrStopCauseExpiredOffline used when restored cooldown has expired
+	// after its snapshot was taken, e.g. while the process was down.
+	ErrStopCauseExpiredOffline = errors.New("cooldown expired offline")
+)
+
+var (
+	// ErrStartCancelled is returned when cooldown start was cancelled by
+	// handler or the duration is not positive.
+	ErrStartCancelled = errors.New("cooldown start cancelled")
+	// ErrExceedsBurst is returned by Bucket.WaitN when n exceeds the burst.
+	ErrExceedsBurst = errors.New("bucket: n exceeds burst")
+	// ErrExceedsDeadline is returned by Bucket.WaitN when the tokens won't be
+	// available before the context deadline.
+	ErrExceedsDeadline = errors.New("bucket: wait would exceed context deadline")
+	// ErrQueueFull is returned by Valued.StartQueued when the queue already
+	// has the maximum count of uses.
+	ErrQueueFull = errors.New("cooldown queue is full")
 )
+
+// ErrOnCooldown is returned when action is rejected because the cooldown is
+// still active.
+type ErrOnCooldown struct {
+	// Remaining is the duration until the cooldown expires.
+	Remaining time.Duration
+}
+
+// Error ...
+func (err *ErrOnCooldown) Error() string {
+	return fmt.Sprintf("on cooldown, %s remaining", err.Remaining)
+}
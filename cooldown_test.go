@@ -1,6 +1,9 @@
 package cooldown_test
 
 import (
+	"errors"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -45,3 +48,257 @@ func TestCoolDownTogglePause(t *testing.T) {
 		}
 	}
 }
+
+type cycleHandler struct {
+	cooldown.NopValuedHandler[int]
+
+	mu         sync.Mutex
+	iterations []int
+	stops      int
+	stopVal    int
+}
+
+func (h *cycleHandler) HandleCycle(_ *cooldown.Valued[int], iteration int, _ int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.iterations = append(h.iterations, iteration)
+}
+
+func (h *cycleHandler) HandleStop(_ *cooldown.Valued[int], _ cooldown.StopCause, val int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.stops++
+	h.stopVal = val
+}
+
+func (h *cycleHandler) result() ([]int, int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]int(nil), h.iterations...), h.stops
+}
+
+func TestValuedRepeating(t *testing.T) {
+	t.Run("limited cycles", func(t *testing.T) {
+		h := new(cycleHandler)
+		c := cooldown.NewValued[int](cooldown.ValuedOptionHandler[int](h))
+		assert.Equal(t, c.StartRepeating(time.Millisecond*10, 3, 1), true)
+		assert.Equal(t, c.Repeating(), true)
+
+		<-time.After(time.Millisecond * 60)
+		iterations, stops := h.result()
+		assert.Equal(t, iterations, []int{0, 1, 2})
+		assert.Equal(t, stops, 1)
+		assert.Equal(t, c.Active(), false)
+		assert.Equal(t, c.Repeating(), false)
+		h.mu.Lock()
+		assert.Equal(t, h.stopVal, 1)
+		h.mu.Unlock()
+	})
+	t.Run("active between cycles", func(t *testing.T) {
+		c := cooldown.NewValued[int]()
+		assert.Equal(t, c.StartRepeating(time.Millisecond*20, 2, 1), true)
+
+		// Lock delays the timer re-arming the cooldown.
+		c.L().Lock()
+		<-time.After(time.Millisecond * 30)
+		assert.Equal(t, c.ActiveUnsafe(), true)
+		remaining := c.RemainingUnsafe()
+		assert.Equal(t, remaining > 0 && remaining <= time.Millisecond*10, true)
+		started, remaining := c.TryStartUnsafe(time.Second, 2)
+		assert.Equal(t, started, false)
+		assert.Equal(t, remaining > 0, true)
+
+		// The second cycle was the last one.
+		<-time.After(time.Millisecond * 20)
+		assert.Equal(t, c.ActiveUnsafe(), false)
+		assert.Equal(t, c.RemainingUnsafe(), time.Duration(0))
+		c.L().Unlock()
+	})
+	t.Run("forever until stopped", func(t *testing.T) {
+		h := new(cycleHandler)
+		c := cooldown.NewValued[int](cooldown.ValuedOptionHandler[int](h))
+		assert.Equal(t, c.StartRepeating(time.Millisecond*5, 0, 1), true)
+
+		<-time.After(time.Millisecond * 40)
+		assert.Equal(t, c.Active(), true)
+		c.Stop(1)
+		iterations, stops := h.result()
+		assert.Equal(t, len(iterations) >= 3, true)
+		assert.Equal(t, stops, 1)
+	})
+}
+
+func TestValuedTryStart(t *testing.T) {
+	c := cooldown.NewValued[int]()
+
+	var (
+		wg      sync.WaitGroup
+		started atomic.Int32
+	)
+	for range 100 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if ok, _ := c.TryStart(time.Second, 1); ok {
+				started.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, started.Load(), int32(1))
+
+	ok, remaining := c.TryStart(time.Second, 1)
+	assert.Equal(t, ok, false)
+	assert.Equal(t, remaining > 0, true)
+}
+
+func TestValuedQueue(t *testing.T) {
+	c := cooldown.NewValued[int](cooldown.ValuedOptionQueue[int](time.Millisecond*30, 1))
+
+	use, err := c.StartQueued(time.Millisecond*50, 1)
+	assert.Equal(t, use == nil, true)
+	assert.Equal(t, err, nil)
+
+	// Outside of the queue window
+	_, err = c.StartQueued(time.Second, 2)
+	var onCooldown *cooldown.ErrOnCooldown
+	assert.Equal(t, errors.As(err, &onCooldown), true)
+
+	<-time.After(time.Millisecond * 30)
+	use, err = c.StartQueued(time.Millisecond*100, 2)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, use.Value(), 2)
+	_, err = c.StartQueued(time.Second, 3)
+	assert.Equal(t, err, cooldown.ErrQueueFull)
+
+	select {
+	case <-use.Done():
+	case <-time.After(time.Second):
+		t.Fatal("queued use must be executed when cooldown expires")
+	}
+	assert.Equal(t, use.Started(), true)
+	assert.Equal(t, c.Remaining() > time.Millisecond*80, true)
+	assert.Equal(t, use.Cancel(), false)
+
+	// Stopping the cooldown cancels queued uses
+	<-time.After(c.Remaining() - time.Millisecond*10)
+	use, _ = c.StartQueued(time.Millisecond*100, 4)
+	c.Stop(0)
+	<-use.Done()
+	assert.Equal(t, use.Started(), false)
+}
+
+func TestValuedQueueExpired(t *testing.T) {
+	c := cooldown.NewValued[int](cooldown.ValuedOptionQueue[int](time.Hour, 2))
+	c.Start(time.Millisecond*20, 1)
+	queued, err := c.StartQueued(time.Minute, 2)
+	assert.Equal(t, err, nil)
+
+	// Lock delays the timer, so the cooldown is expired with the queued use.
+	c.L().Lock()
+	<-time.After(time.Millisecond * 40)
+	use, err := c.StartQueuedUnsafe(time.Minute, 3)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, use != nil, true)
+	select {
+	case <-queued.Done():
+	default:
+		t.Fatal("earlier queued use must be executed first")
+	}
+	assert.Equal(t, c.SnapshotUnsafe().Val, 2)
+	c.L().Unlock()
+	assert.Equal(t, use.Cancel(), true)
+}
+
+// restartHandler restarts the cooldown from HandleStop once it expires.
+type restartHandler struct {
+	cooldown.NopValuedHandler[int]
+	activeOnStop chan bool
+}
+
+func (h restartHandler) HandleStop(cd *cooldown.Valued[int], cause cooldown.StopCause, _ int) {
+	if cause != cooldown.ErrStopCauseExpired {
+		return
+	}
+	h.activeOnStop <- cd.ActiveUnsafe()
+	cd.StartUnsafe(time.Minute, 2)
+}
+
+func TestValuedExpireStop(t *testing.T) {
+	h := restartHandler{activeOnStop: make(chan bool, 1)}
+	cd := cooldown.NewValued[int](cooldown.ValuedOptionHandler[int](h))
+	cd.Start(time.Millisecond*10, 1)
+
+	// Cooldown is already stopped when HandleStop is called on expiration,
+	// so it can be started again from the handler.
+	assert.Equal(t, <-h.activeOnStop, false)
+	assert.Equal(t, cd.Active(), true)
+	assert.Equal(t, cd.Duration(), time.Minute)
+	cd.Stop(0)
+}
+
+func TestValuedClock(t *testing.T) {
+	clock := newFakeClock()
+	h := restartHandler{activeOnStop: make(chan bool, 1)}
+	cd := cooldown.NewValued[int](cooldown.ValuedOptionClock[int](clock), cooldown.ValuedOptionHandler[int](h))
+
+	assert.Equal(t, cd.Start(time.Hour, 1), true)
+	assert.Equal(t, cd.Remaining(), time.Hour)
+	clock.Advance(time.Minute * 59)
+	assert.Equal(t, cd.Remaining(), time.Minute)
+	assert.Equal(t, cd.Pause(1), true)
+	clock.Advance(time.Hour)
+	assert.Equal(t, cd.Resume(1), true)
+	assert.Equal(t, cd.Active(), true)
+
+	// Timer fires on the clock time, and the handler restarts the cooldown.
+	clock.Advance(time.Minute)
+	assert.Equal(t, <-h.activeOnStop, false)
+	assert.Equal(t, cd.Remaining(), time.Minute)
+	clock.Advance(time.Minute)
+	assert.Equal(t, <-h.activeOnStop, false)
+}
+
+// toleranceHandler records values of the stops and cancels starts with
+// negative values.
+type toleranceHandler struct {
+	cooldown.NopValuedHandler[int]
+	stopped []int
+}
+
+func (h *toleranceHandler) HandleStart(ctx *cooldown.ValuedContext[int], _ time.Duration, val int) {
+	if val < 0 {
+		ctx.Cancel()
+	}
+}
+
+func (h *toleranceHandler) HandleStop(_ *cooldown.Valued[int], cause cooldown.StopCause, val int) {
+	if cause == cooldown.ErrStopCauseExpired {
+		h.stopped = append(h.stopped, val)
+	}
+}
+
+func TestValuedTolerance(t *testing.T) {
+	clock, h := newFakeClock(), new(toleranceHandler)
+	cd := cooldown.NewValued[int](cooldown.ValuedOptionClock[int](clock), cooldown.ValuedOptionHandler[int](h))
+	cd.Start(time.Second, 1)
+	clock.Advance(time.Millisecond * 900)
+
+	// Cancelled start doesn't expire the cooldown.
+	started, _ := cd.TryStartWithTolerance(time.Second, time.Millisecond*200, -1)
+	assert.Equal(t, started, false)
+	assert.Equal(t, cd.Remaining(), time.Millisecond*100)
+	assert.Equal(t, len(h.stopped), 0)
+
+	// Paused cooldown never expires, so it is not ready.
+	cd.Pause(1)
+	assert.Equal(t, cd.ReadyWithTolerance(time.Millisecond*200), false)
+	cd.Resume(1)
+
+	started, early := cd.TryStartWithTolerance(time.Second, time.Millisecond*200, 2)
+	assert.Equal(t, started, true)
+	assert.Equal(t, early, time.Millisecond*100)
+	assert.Equal(t, h.stopped, []int{1})
+	assert.Equal(t, cd.ExpiredAt(), clock.Now())
+	assert.Equal(t, cd.Remaining(), time.Second)
+}
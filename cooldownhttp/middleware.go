@@ -0,0 +1,217 @@
+// Package cooldownhttp provides net/http middleware that puts endpoints on a
+// cooldown per client, e.g. per IP address, API key or user ID.
+package cooldownhttp
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/k4ties/cooldown"
+)
+
+// ErrInvalidDuration is returned by New if the cooldown duration is not
+// positive.
+var ErrInvalidDuration = errors.New("cooldownhttp: cooldown duration must be positive")
+
+const (
+	// HeaderRetryAfter is the header with count of seconds the client must wait
+	// before the next request.
+	HeaderRetryAfter = "Retry-After"
+	// HeaderRemaining is the header with count of milliseconds until the
+	// cooldown of the client expires. It is added only if OptionRemainingHeader
+	// is used.
+	HeaderRemaining = "X-Cooldown-Remaining"
+)
+
+// KeyFunc extracts the key of the client from the request. If ok is false,
+// the request is passed through without a cooldown.
+type KeyFunc func(r *http.Request) (key string, ok bool)
+
+// RemoteIP is KeyFunc that uses IP address of the client. Note that it uses
+// the address of the direct peer, so it is a proxy address if the server is
+// behind one.
+func RemoteIP(r *http.Request) (string, bool) {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr, r.RemoteAddr != ""
+	}
+	return host, true
+}
+
+// Header returns KeyFunc that uses value of the header with provided name,
+// e.g. the API key. Requests without the header are passed through.
+func Header(name string) KeyFunc {
+	return func(r *http.Request) (string, bool) {
+		v := r.Header.Get(name)
+		return v, v != ""
+	}
+}
+
+// BlockedFunc writes the response to the request that was blocked by the
+// cooldown of the client.
+type BlockedFunc func(w http.ResponseWriter, r *http.Request, remaining time.Duration)
+
+// Option is the option of Middleware.
+type Option func(*Middleware)
+
+// OptionKey sets the function that extracts keys of the clients. Default is
+// RemoteIP.
+func OptionKey(fn KeyFunc) Option {
+	return func(m *Middleware) {
+		if fn != nil {
+			m.key = fn
+		}
+	}
+}
+
+// OptionRemainingHeader enables HeaderRemaining in the responses. Passed
+// requests have the duration of the started cooldown in it, and blocked ones
+// have the remaining duration.
+func OptionRemainingHeader() Option {
+	return func(m *Middleware) {
+		m.remainingHeader = true
+	}
+}
+
+// OptionBlocked sets the function that writes responses to blocked requests.
+// Headers are already set when it is called. Default responds with
+// http.StatusTooManyRequests and the status text.
+func OptionBlocked(fn BlockedFunc) Option {
+	return func(m *Middleware) {
+		if fn != nil {
+			m.blocked = fn
+		}
+	}
+}
+
+// OptionRegistry sets the registry the cooldowns are stored in, e.g. to share
+// it between several middlewares, or to use its constraints. Requests whose
+// cooldowns are rejected by the constraints (with *cooldown.ConstraintError)
+// are blocked like the ones on cooldown. Other errors, e.g. starts cancelled
+// by the handlers of the registry, are answered with
+// http.StatusInternalServerError, since they aren't caused by the client.
+//
+// The middleware prunes the registry periodically, see Middleware.Registry.
+func OptionRegistry(reg *cooldown.Registry[string, struct{}]) Option {
+	return func(m *Middleware) {
+		if reg != nil {
+			m.reg = reg
+		}
+	}
+}
+
+// Middleware starts cooldown of the client on every passed request, and
+// rejects requests of the clients that are still on cooldown.
+type Middleware struct {
+	dur time.Duration
+	reg *cooldown.Registry[string, struct{}]
+
+	key             KeyFunc
+	blocked         BlockedFunc
+	remainingHeader bool
+
+	// pruned is the time in Unix nanoseconds the registry was last pruned at.
+	pruned atomic.Int64
+}
+
+// New creates new Middleware with provided cooldown duration. Returns
+// ErrInvalidDuration if the duration is not positive.
+func New(dur time.Duration, opts ...Option) (*Middleware, error) {
+	if dur <= 0 {
+		return nil, ErrInvalidDuration
+	}
+	m := &Middleware{dur: dur, key: RemoteIP, blocked: TooManyRequests}
+	for _, opt := range opts {
+		if opt == nil {
+			continue
+		}
+		opt(m)
+	}
+	if m.reg == nil {
+		m.reg = cooldown.NewRegistry[string, struct{}]()
+	}
+	return m, nil
+}
+
+// Registry returns the registry the cooldowns are stored in. Expired cooldowns
+// of the clients are removed by the middleware: registry is pruned on the
+// first request after every cooldown duration.
+func (m *Middleware) Registry() *cooldown.Registry[string, struct{}] {
+	return m.reg
+}
+
+// Handler wraps next, so it is called only if the client is not on cooldown.
+func (m *Middleware) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key, ok := m.key(r)
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+		m.prune()
+		if err := m.reg.TryStart(key, m.dur, struct{}{}); err != nil {
+			remaining, ok := blockedFor(err)
+			if !ok {
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				return
+			}
+			if remaining > 0 {
+				// Remaining duration of the constraint may be unknown.
+				w.Header().Set(HeaderRetryAfter, strconv.FormatInt(retryAfter(remaining), 10))
+			}
+			if m.remainingHeader {
+				w.Header().Set(HeaderRemaining, strconv.FormatInt(remaining.Milliseconds(), 10))
+			}
+			m.blocked(w, r, remaining)
+			return
+		}
+		if m.remainingHeader {
+			w.Header().Set(HeaderRemaining, strconv.FormatInt(m.dur.Milliseconds(), 10))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// prune prunes the registry, if the cooldown duration has passed since it was
+// last pruned, so cooldowns of the clients that are gone don't pile up.
+func (m *Middleware) prune() {
+	now, last := time.Now().UnixNano(), m.pruned.Load()
+	if now-last < int64(m.dur) || !m.pruned.CompareAndSwap(last, now) {
+		return
+	}
+	m.reg.Prune()
+}
+
+// blockedFor returns the duration the request is blocked for by the error of
+// the start, and false if the error doesn't block it.
+func blockedFor(err error) (time.Duration, bool) {
+	var errOnCooldown *cooldown.ErrOnCooldown
+	if errors.As(err, &errOnCooldown) {
+		return errOnCooldown.Remaining, true
+	}
+	var constraintErr *cooldown.ConstraintError[string]
+	if errors.As(err, &constraintErr) {
+		return constraintErr.Remaining, true
+	}
+	return 0, false
+}
+
+// HandlerFunc is Handler for plain functions.
+func (m *Middleware) HandlerFunc(next http.HandlerFunc) http.Handler {
+	return m.Handler(next)
+}
+
+// TooManyRequests is the default BlockedFunc.
+func TooManyRequests(w http.ResponseWriter, _ *http.Request, _ time.Duration) {
+	http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+}
+
+// retryAfter returns count of whole seconds the client must wait, rounded up,
+// so the client doesn't retry while the cooldown is still active.
+func retryAfter(remaining time.Duration) int64 {
+	return int64((remaining + time.Second - 1) / time.Second)
+}
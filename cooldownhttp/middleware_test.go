@@ -0,0 +1,146 @@
+package cooldownhttp_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+	"github.com/k4ties/cooldown"
+	"github.com/k4ties/cooldown/cooldownhttp"
+)
+
+func TestMiddleware(t *testing.T) {
+	m, err := cooldownhttp.New(time.Millisecond*1500, cooldownhttp.OptionRemainingHeader())
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := m.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	serve := func(addr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = addr
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := serve("10.0.0.1:1234")
+	assert.Equal(t, rec.Code, http.StatusNoContent)
+	assert.Equal(t, rec.Header().Get(cooldownhttp.HeaderRemaining), "1500")
+
+	// Port is ignored, so the client is still on cooldown
+	rec = serve("10.0.0.1:4321")
+	assert.Equal(t, rec.Code, http.StatusTooManyRequests)
+	assert.Equal(t, rec.Header().Get(cooldownhttp.HeaderRetryAfter), "2")
+	assert.NotEqual(t, rec.Header().Get(cooldownhttp.HeaderRemaining), "")
+
+	assert.Equal(t, serve("10.0.0.2:1234").Code, http.StatusNoContent)
+}
+
+func TestMiddlewareKey(t *testing.T) {
+	var blocked time.Duration
+	m, err := cooldownhttp.New(time.Second,
+		cooldownhttp.OptionKey(cooldownhttp.Header("X-API-Key")),
+		cooldownhttp.OptionBlocked(func(w http.ResponseWriter, _ *http.Request, remaining time.Duration) {
+			blocked = remaining
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(m.Handler(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})))
+	defer srv.Close()
+
+	get := func(key string) *http.Response {
+		req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+		if key != "" {
+			req.Header.Set("X-API-Key", key)
+		}
+		res, err := srv.Client().Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = res.Body.Close()
+		return res
+	}
+
+	assert.Equal(t, get("a").StatusCode, http.StatusOK)
+	res := get("a")
+	assert.Equal(t, res.StatusCode, http.StatusServiceUnavailable)
+	assert.Equal(t, res.Header.Get(cooldownhttp.HeaderRetryAfter), "1")
+	assert.Equal(t, res.Header.Get(cooldownhttp.HeaderRemaining), "")
+	assert.Equal(t, blocked > 0, true)
+
+	// Requests without the key aren't limited
+	assert.Equal(t, get("").StatusCode, http.StatusOK)
+	assert.Equal(t, get("").StatusCode, http.StatusOK)
+	assert.Equal(t, m.Registry().Len(), 1)
+}
+
+func TestMiddlewareErrors(t *testing.T) {
+	_, err := cooldownhttp.New(0)
+	assert.Equal(t, err, cooldownhttp.ErrInvalidDuration)
+
+	errDenied := errors.New("denied")
+	reg := cooldown.NewRegistry(cooldown.RegistryOptionConstraint(
+		func(*cooldown.Registry[string, struct{}], string, struct{}) error {
+			return errDenied
+		},
+	))
+	m, err := cooldownhttp.New(time.Second, cooldownhttp.OptionRegistry(reg))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := httptest.NewRecorder()
+	m.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		t.Fatal("request must not pass")
+	}).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	// Errors that aren't caused by the client are answered with 500.
+	assert.Equal(t, rec.Code, http.StatusInternalServerError)
+	assert.Equal(t, rec.Header().Get(cooldownhttp.HeaderRetryAfter), "")
+
+	// Constraints of the registry block the requests.
+	reg = cooldown.NewRegistry(cooldown.RegistryOptionConstraint(
+		func(_ *cooldown.Registry[string, struct{}], key string, _ struct{}) error {
+			return &cooldown.ConstraintError[string]{Key: key, Reason: cooldown.ReasonExcluded, Remaining: time.Second * 3}
+		},
+	))
+	m, err = cooldownhttp.New(time.Second, cooldownhttp.OptionRegistry(reg))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec = httptest.NewRecorder()
+	m.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		t.Fatal("request must not pass")
+	}).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, rec.Code, http.StatusTooManyRequests)
+	assert.Equal(t, rec.Header().Get(cooldownhttp.HeaderRetryAfter), "3")
+}
+
+func TestMiddlewarePrune(t *testing.T) {
+	m, err := cooldownhttp.New(time.Millisecond * 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := m.HandlerFunc(func(http.ResponseWriter, *http.Request) {})
+	serve := func(addr string) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = addr
+		h.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	for _, addr := range []string{"10.0.0.1:1", "10.0.0.2:1", "10.0.0.3:1"} {
+		serve(addr)
+	}
+	assert.Equal(t, m.Registry().Len(), 3)
+	// Cooldowns of the clients that are gone are removed.
+	<-time.After(time.Millisecond * 20)
+	serve("10.0.0.4:1")
+	assert.Equal(t, m.Registry().Len(), 1)
+}
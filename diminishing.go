@@ -0,0 +1,150 @@
+package cooldown
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultDiminishingSteps is the common table of diminishing returns: full
+// duration, then 50%, then 25%, then immunity.
+var DefaultDiminishingSteps = []float64{1, 0.5, 0.25, 0}
+
+// Diminishing tracks diminishing returns of repeated effects per target and
+// effect category. Every application within the window after the previous one
+// lasts shorter, according to the step table of the category. The window is
+// refreshed by every application that isn't fully diminished.
+type Diminishing[K, C comparable] struct {
+	mu sync.Mutex
+
+	window time.Duration
+	steps  []float64
+	tables map[C][]float64
+	clock  Clock
+
+	entries map[diminishingKey[K, C]]diminishingEntry
+}
+
+type diminishingKey[K, C comparable] struct {
+	target   K
+	category C
+}
+
+type diminishingEntry struct {
+	// applied is the count of applications in the current window.
+	applied int
+	// reset is the time when the window ends.
+	reset time.Time
+}
+
+// NewDiminishing creates new Diminishing tracker with provided window. Steps
+// are multipliers of the effect duration for the first, second, etc.
+// application, the last one is used for all the next applications. If steps
+// are empty, DefaultDiminishingSteps are used.
+func NewDiminishing[K, C comparable](window time.Duration, steps []float64, opts ...DiminishingOption[K, C]) *Diminishing[K, C] {
+	if len(steps) == 0 {
+		steps = DefaultDiminishingSteps
+	}
+	d := &Diminishing[K, C]{
+		window:  window,
+		steps:   append([]float64(nil), steps...),
+		tables:  make(map[C][]float64),
+		clock:   SystemClock{},
+		entries: make(map[diminishingKey[K, C]]diminishingEntry),
+	}
+	for _, opt := range opts {
+		if opt == nil {
+			continue
+		}
+		opt(d)
+	}
+	return d
+}
+
+// Apply registers application of the effect with provided duration and
+// returns its effective duration, that should be passed to Valued.Start.
+// Zero means that the target is immune to the effect.
+func (d *Diminishing[K, C]) Apply(target K, category C, dur time.Duration) time.Duration {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := d.clock.Now()
+	key := diminishingKey[K, C]{target: target, category: category}
+	entry := d.entryUnsafe(key, now)
+	effective := d.effectiveUnsafe(category, entry.applied, dur)
+	if effective <= 0 {
+		// Immune applications don't refresh the window.
+		return 0
+	}
+	entry.applied++
+	entry.reset = now.Add(d.window)
+	d.entries[key] = entry
+	return effective
+}
+
+// Peek returns effective duration of the effect without applying it.
+func (d *Diminishing[K, C]) Peek(target K, category C, dur time.Duration) time.Duration {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	entry := d.entryUnsafe(diminishingKey[K, C]{target: target, category: category}, d.clock.Now())
+	return d.effectiveUnsafe(category, entry.applied, dur)
+}
+
+// Level returns count of applications in the current window.
+func (d *Diminishing[K, C]) Level(target K, category C) int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.entryUnsafe(diminishingKey[K, C]{target: target, category: category}, d.clock.Now()).applied
+}
+
+// ResetsIn returns duration until diminishing returns of the category are
+// reset for the target.
+func (d *Diminishing[K, C]) ResetsIn(target K, category C) time.Duration {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	now := d.clock.Now()
+	entry := d.entryUnsafe(diminishingKey[K, C]{target: target, category: category}, now)
+	if entry.applied == 0 {
+		return 0
+	}
+	return entry.reset.Sub(now)
+}
+
+// Reset resets diminishing returns of the category for the target.
+func (d *Diminishing[K, C]) Reset(target K, category C) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.entries, diminishingKey[K, C]{target: target, category: category})
+}
+
+// Prune removes entries whose window has ended.
+func (d *Diminishing[K, C]) Prune() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	now := d.clock.Now()
+	for key, entry := range d.entries {
+		if !entry.reset.After(now) {
+			delete(d.entries, key)
+		}
+	}
+}
+
+// entryUnsafe returns entry with provided key, resetting it if its window has
+// ended.
+func (d *Diminishing[K, C]) entryUnsafe(key diminishingKey[K, C], now time.Time) diminishingEntry {
+	entry, ok := d.entries[key]
+	if ok && !entry.reset.After(now) {
+		delete(d.entries, key)
+		return diminishingEntry{}
+	}
+	return entry
+}
+
+// effectiveUnsafe returns duration of the application with provided index.
+func (d *Diminishing[K, C]) effectiveUnsafe(category C, applied int, dur time.Duration) time.Duration {
+	steps, ok := d.tables[category]
+	if !ok {
+		steps = d.steps
+	}
+	multiplier := steps[min(applied, len(steps)-1)]
+	return time.Duration(float64(dur) * multiplier)
+}
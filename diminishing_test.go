@@ -0,0 +1,33 @@
+package cooldown_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+	"github.com/k4ties/cooldown"
+)
+
+func TestDiminishing(t *testing.T) {
+	clock := newFakeClock()
+	d := cooldown.NewDiminishing(time.Second*18, nil,
+		cooldown.DiminishingOptionClock[string, string](clock),
+		cooldown.DiminishingOptionTable[string, string]("root", 1, 0.5),
+	)
+
+	for _, want := range []time.Duration{time.Second * 4, time.Second * 2, time.Second, 0, 0} {
+		assert.Equal(t, d.Apply("target", "stun", time.Second*4), want)
+		clock.Advance(time.Second)
+	}
+	assert.Equal(t, d.Level("target", "stun"), 3)
+	// Other target and category aren't affected
+	assert.Equal(t, d.Peek("other", "stun", time.Second*4), time.Second*4)
+	assert.Equal(t, d.Apply("target", "root", time.Second*4), time.Second*4)
+	assert.Equal(t, d.Apply("target", "root", time.Second*4), time.Second*2)
+	assert.Equal(t, d.Apply("target", "root", time.Second*4), time.Second*2)
+
+	// Immune applications didn't refresh the window
+	clock.Advance(time.Second * 15)
+	assert.Equal(t, d.ResetsIn("target", "stun"), time.Duration(0))
+	assert.Equal(t, d.Apply("target", "stun", time.Second*4), time.Second*4)
+}
@@ -0,0 +1,472 @@
+// Package filestore implements cooldown.Store that keeps records in memory
+// and persists every change to the append-only log file, so cooldowns survive
+// restarts of a single-node server without a database.
+//
+// The directory of the store contains two files: the log, where every
+// change of the records is appended to, and the snapshot, that contains all
+// the records at the time of the last compaction. When the store is opened,
+// the snapshot is loaded and the log is replayed over it. If the process
+// crashed in the middle of the write, the incomplete record at the end of the
+// log is discarded. Corrupted records in the middle of the log are not
+// discarded, since the records after them can't be trusted, and ErrCorrupted
+// is returned instead.
+package filestore
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/k4ties/cooldown"
+)
+
+const (
+	// LogName is the name of the log file in the directory of the store.
+	LogName = "cooldowns.log"
+	// SnapshotName is the name of the snapshot file in the directory of the
+	// store.
+	SnapshotName = "cooldowns.snapshot"
+)
+
+const (
+	entrySet byte = iota + 1
+	entryDelete
+)
+
+// headerSize is the size of the entry header: length and checksum of the
+// payload, and checksum of both of them. The length is protected by its own
+// checksum, so corrupted length is never taken for the entry cut by the
+// crash.
+const headerSize = 12
+
+// maxEntrySize limits size of the single entry, so corrupted length doesn't
+// cause huge allocation.
+const maxEntrySize = 1 << 24
+
+// ErrCorrupted is returned by Open if the log or the snapshot contains
+// corrupted entry that wasn't written during the crash.
+var ErrCorrupted = errors.New("filestore: corrupted entry")
+
+// Option is the option of the Store.
+type Option func(s *Store)
+
+// OptionCompactEvery sets count of the log entries after which the store is
+// compacted automatically. Zero disables automatic compaction. Default is
+// 1000.
+func OptionCompactEvery(n int) Option {
+	return func(s *Store) {
+		s.compactEvery = max(n, 0)
+	}
+}
+
+// OptionSync makes the store sync the log to the disk after every change.
+// It is much slower, but changes are not lost even if the machine crashes,
+// not just the process.
+func OptionSync() Option {
+	return func(s *Store) {
+		s.sync = true
+	}
+}
+
+// Store is the file-backed cooldown.Store.
+type Store struct {
+	mu sync.RWMutex
+
+	dir     string
+	log     *os.File
+	records map[string]cooldown.Record
+	// entries is count of the entries in the log, and size is its size.
+	entries int
+	size    int64
+
+	compactEvery int
+	sync         bool
+	closed       bool
+}
+
+// Open opens the store in provided directory, creating it if it doesn't
+// exist, and restores the records from its files.
+func Open(dir string, opts ...Option) (*Store, error) {
+	s := &Store{dir: dir, records: make(map[string]cooldown.Record), compactEvery: 1000}
+	for _, opt := range opts {
+		if opt == nil {
+			continue
+		}
+		opt(s)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	if err := s.loadSnapshot(); err != nil {
+		return nil, err
+	}
+	if err := s.replayLog(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// loadSnapshot loads the records from the snapshot file, if it exists.
+func (s *Store) loadSnapshot() error {
+	f, err := os.Open(filepath.Join(s.dir, SnapshotName))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	// Snapshot is written atomically, so it must never be corrupted.
+	r := bufio.NewReader(f)
+	for {
+		_, err = s.readEntry(r)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return errors.Join(errors.New("read snapshot"), err)
+		}
+	}
+}
+
+// replayLog applies the entries of the log, and truncates it after the last
+// complete entry.
+func (s *Store) replayLog() error {
+	f, err := os.OpenFile(filepath.Join(s.dir, LogName), os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return err
+	}
+	var (
+		r      = bufio.NewReader(f)
+		offset int64
+	)
+	for {
+		n, err := s.readEntry(r)
+		if err == nil {
+			offset += n
+			s.entries++
+			continue
+		}
+		switch {
+		case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+			// Length of the entry is checked, so it really doesn't fit into
+			// the file and nothing can be written after it.
+			err = nil
+		case errors.Is(err, ErrCorrupted):
+			var tail bool
+			if tail, err = corruptedTail(f, offset, n); err == nil && !tail {
+				err = fmt.Errorf("%w at offset %d of the log", ErrCorrupted, offset)
+			}
+		}
+		if err != nil {
+			_ = f.Close()
+			return err
+		}
+		break
+	}
+	// Discard the incomplete entry written during the crash.
+	if err = f.Truncate(offset); err == nil {
+		_, err = f.Seek(offset, io.SeekStart)
+	}
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+	s.log, s.size = f, offset
+	return nil
+}
+
+// corruptedTail reports whether the corrupted entry at provided offset of the
+// file with provided size n was written during the crash, so it is the last
+// one. If n is zero, the header itself is corrupted, and the rest of the file
+// must be zeroed, e.g. allocated by the filesystem but never written.
+func corruptedTail(f *os.File, offset, n int64) (bool, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return false, err
+	}
+	if n > 0 {
+		return offset+n == info.Size(), nil
+	}
+	rest, err := io.ReadAll(io.NewSectionReader(f, offset, info.Size()-offset))
+	if err != nil {
+		return false, err
+	}
+	return !slices.ContainsFunc(rest, func(b byte) bool {
+		return b != 0
+	}), nil
+}
+
+// readEntry reads and applies the single entry. Returns size of the entry,
+// which is also known if only the checksum doesn't match.
+func (s *Store) readEntry(r io.Reader) (int64, error) {
+	var header [headerSize]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return 0, err
+	}
+	if crc32.ChecksumIEEE(header[:8]) != binary.LittleEndian.Uint32(header[8:]) {
+		return 0, ErrCorrupted
+	}
+	size := binary.LittleEndian.Uint32(header[:4])
+	if size == 0 || size > maxEntrySize {
+		return 0, ErrCorrupted
+	}
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return 0, err
+	}
+	if crc32.ChecksumIEEE(payload) != binary.LittleEndian.Uint32(header[4:]) {
+		return headerSize + int64(size), ErrCorrupted
+	}
+	if err := s.applyEntry(payload); err != nil {
+		return 0, err
+	}
+	return headerSize + int64(size), nil
+}
+
+func (s *Store) applyEntry(payload []byte) error {
+	kind, payload := payload[0], payload[1:]
+	keyLen, n := binary.Uvarint(payload)
+	if n <= 0 || keyLen > uint64(len(payload)-n) {
+		return ErrCorrupted
+	}
+	key, payload := string(payload[n:n+int(keyLen)]), payload[n+int(keyLen):]
+	switch kind {
+	case entrySet:
+		var rec cooldown.Record
+		if err := rec.UnmarshalBinary(payload); err != nil {
+			return ErrCorrupted
+		}
+		s.records[key] = rec
+	case entryDelete:
+		delete(s.records, key)
+	default:
+		return ErrCorrupted
+	}
+	return nil
+}
+
+// appendEntry encodes the entry with its header to b.
+func appendEntry(b []byte, kind byte, key string, rec *cooldown.Record) []byte {
+	start := len(b)
+	b = append(b, make([]byte, headerSize)...)
+	b = append(b, kind)
+	b = binary.AppendUvarint(b, uint64(len(key)))
+	b = append(b, key...)
+	if rec != nil {
+		b, _ = rec.AppendBinary(b)
+	}
+	payload := b[start+headerSize:]
+	binary.LittleEndian.PutUint32(b[start:], uint32(len(payload)))
+	binary.LittleEndian.PutUint32(b[start+4:], crc32.ChecksumIEEE(payload))
+	binary.LittleEndian.PutUint32(b[start+8:], crc32.ChecksumIEEE(b[start:start+8]))
+	return b
+}
+
+// writeUnsafe appends the entry to the log.
+func (s *Store) writeUnsafe(kind byte, key string, rec *cooldown.Record) error {
+	if s.closed {
+		return os.ErrClosed
+	}
+	b := appendEntry(nil, kind, key, rec)
+	if _, err := s.log.Write(b); err != nil {
+		// Remove partially written entry, so the next ones can be replayed.
+		s.discardUnsafe()
+		return err
+	}
+	if s.sync {
+		if err := s.log.Sync(); err != nil {
+			// The change is reported as failed, so it must not be replayed.
+			s.discardUnsafe()
+			return err
+		}
+	}
+	s.size += int64(len(b))
+	s.entries++
+	return nil
+}
+
+// discardUnsafe removes everything written to the log after its last entry.
+func (s *Store) discardUnsafe() {
+	if s.log.Truncate(s.size) == nil {
+		_, _ = s.log.Seek(s.size, io.SeekStart)
+	}
+}
+
+// maybeCompactUnsafe compacts the store if there are too many entries in the
+// log. It must be called only after the written entry is applied.
+func (s *Store) maybeCompactUnsafe() {
+	if s.compactEvery > 0 && s.entries >= s.compactEvery {
+		// The entry is already in the log, so failed compaction doesn't lose
+		// it, and it is retried on the next write.
+		_ = s.compactUnsafe()
+	}
+}
+
+// Get ...
+func (s *Store) Get(_ context.Context, key string) (cooldown.Record, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	rec, ok := s.records[key]
+	rec.Value = slices.Clone(rec.Value)
+	return rec, ok, nil
+}
+
+// CompareAndSet ...
+func (s *Store) CompareAndSet(_ context.Context, key string, version uint64, rec cooldown.Record) (uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.records[key].Version != version {
+		return 0, cooldown.ErrVersionConflict
+	}
+	rec.Value = slices.Clone(rec.Value)
+	rec.Version = version + 1
+	// The record is applied only after it is written, so the state in memory
+	// never differs from the one restored from the files.
+	if err := s.writeUnsafe(entrySet, key, &rec); err != nil {
+		return 0, err
+	}
+	s.records[key] = rec
+	s.maybeCompactUnsafe()
+	return rec.Version, nil
+}
+
+// Delete ...
+func (s *Store) Delete(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.records[key]; !ok {
+		return nil
+	}
+	if err := s.writeUnsafe(entryDelete, key, nil); err != nil {
+		return err
+	}
+	delete(s.records, key)
+	s.maybeCompactUnsafe()
+	return nil
+}
+
+// ScanPrefix ...
+func (s *Store) ScanPrefix(ctx context.Context, prefix string, fn func(key string, rec cooldown.Record) bool) error {
+	s.mu.RLock()
+	keys := make([]string, 0, len(s.records))
+	for key := range s.records {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	s.mu.RUnlock()
+	slices.Sort(keys)
+
+	for _, key := range keys {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		rec, ok, _ := s.Get(ctx, key)
+		if !ok {
+			// Deleted during the scan.
+			continue
+		}
+		if !fn(key, rec) {
+			return nil
+		}
+	}
+	return nil
+}
+
+// Len returns count of the records.
+func (s *Store) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.records)
+}
+
+// Compact writes all the records to the new snapshot and truncates the log.
+func (s *Store) Compact() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return os.ErrClosed
+	}
+	return s.compactUnsafe()
+}
+
+func (s *Store) compactUnsafe() error {
+	keys := slices.Sorted(func(yield func(string) bool) {
+		for key := range s.records {
+			if !yield(key) {
+				return
+			}
+		}
+	})
+	var b []byte
+	for _, key := range keys {
+		rec := s.records[key]
+		b = appendEntry(b, entrySet, key, &rec)
+	}
+
+	// Snapshot is replaced atomically. If the process crashes before the log
+	// is truncated, the log is replayed over the new snapshot, which results
+	// in the same records.
+	tmp := filepath.Join(s.dir, SnapshotName+".tmp")
+	if err := writeFileSync(tmp, b); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, filepath.Join(s.dir, SnapshotName)); err != nil {
+		return err
+	}
+	if err := syncDir(s.dir); err != nil {
+		return err
+	}
+	if err := s.log.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := s.log.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	s.entries, s.size = 0, 0
+	return nil
+}
+
+// Close closes the log file. The store must not be used after it is closed.
+func (s *Store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	return errors.Join(s.log.Sync(), s.log.Close())
+}
+
+func writeFileSync(name string, b []byte) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	if _, err = f.Write(b); err == nil {
+		err = f.Sync()
+	}
+	return errors.Join(err, f.Close())
+}
+
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	// Syncing directories is not supported on some platforms.
+	_ = d.Sync()
+	return nil
+}
@@ -0,0 +1,226 @@
+package filestore_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/k4ties/cooldown"
+	"github.com/k4ties/cooldown/filestore"
+	"github.com/k4ties/cooldown/storetest"
+)
+
+func open(t *testing.T, dir string, opts ...filestore.Option) *filestore.Store {
+	t.Helper()
+	s, err := filestore.Open(dir, opts...)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+	return s
+}
+
+func TestStore(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) cooldown.Store {
+		return open(t, t.TempDir(), filestore.OptionCompactEvery(7))
+	})
+}
+
+func TestReplay(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	s := open(t, dir, filestore.OptionCompactEvery(0))
+	reg := cooldown.NewStoredRegistry[string](s, "")
+
+	if err := reg.Start(ctx, "a", time.Hour, "first"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := reg.Pause(ctx, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if err := reg.Start(ctx, "b", time.Hour, "second"); err != nil {
+		t.Fatal(err)
+	}
+	if err := reg.Stop(ctx, "b"); err != nil {
+		t.Fatal(err)
+	}
+	if err := reg.Start(ctx, "c", time.Hour, "third"); err != nil {
+		t.Fatal(err)
+	}
+	if err := reg.Delete(ctx, "c"); err != nil {
+		t.Fatal(err)
+	}
+	want, _, _ := s.Get(ctx, "a")
+	_ = s.Close()
+
+	s = open(t, dir)
+	if s.Len() != 2 {
+		t.Fatalf("Len() = %d, want 2", s.Len())
+	}
+	got, _, _ := s.Get(ctx, "a")
+	if err := storetest.Equal(got, want); err != nil {
+		t.Fatal(err)
+	}
+	if got.Op != cooldown.OpPause {
+		t.Fatalf("op = %v, want pause", got.Op)
+	}
+	b, _, _ := s.Get(ctx, "b")
+	if b.Op != cooldown.OpStop || b.Version != 2 {
+		t.Fatalf("b = %+v, want stopped record with version 2", b)
+	}
+}
+
+func TestCompaction(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	s := open(t, dir, filestore.OptionCompactEvery(10))
+	for i := range 20 {
+		key := string(rune('a' + i%5))
+		rec, _, _ := s.Get(ctx, key)
+		rec.Duration = time.Duration(i)
+		if _, err := s.CompareAndSet(ctx, key, rec.Version, rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The last write compacted the store, so it must be in the snapshot
+	name := filepath.Join(dir, filestore.LogName)
+	if info, err := os.Stat(name); err != nil || info.Size() != 0 {
+		t.Fatalf("log was not truncated: %v", err)
+	}
+	_ = s.Close()
+
+	s = open(t, dir)
+	for i := range 5 {
+		rec, ok, _ := s.Get(ctx, string(rune('a'+i)))
+		if !ok || rec.Duration != time.Duration(15+i) || rec.Version != 4 {
+			t.Fatalf("record %d = %+v, want duration %d and version 4", i, rec, 15+i)
+		}
+	}
+
+	if err := s.Delete(ctx, "e"); err != nil {
+		t.Fatal(err)
+	}
+	if info, _ := os.Stat(name); info.Size() == 0 {
+		t.Fatal("delete was not written to the log")
+	}
+	if err := s.Compact(); err != nil {
+		t.Fatal(err)
+	}
+	if info, _ := os.Stat(name); info.Size() != 0 {
+		t.Fatalf("log size = %d after compaction", info.Size())
+	}
+	_ = s.Close()
+
+	if s = open(t, dir); s.Len() != 4 {
+		t.Fatalf("Len() = %d, want 4", s.Len())
+	}
+}
+
+func TestCrashRecovery(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	s := open(t, dir, filestore.OptionCompactEvery(0))
+	for _, key := range []string{"a", "b", "c"} {
+		if _, err := s.CompareAndSet(ctx, key, 0, storetest.Record(cooldown.OpStart, key)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_ = s.Close()
+
+	// Simulate crash in the middle of writing the last entry
+	name := filepath.Join(dir, filestore.LogName)
+	info, err := os.Stat(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.Truncate(name, info.Size()-3); err != nil {
+		t.Fatal(err)
+	}
+
+	s = open(t, dir, filestore.OptionCompactEvery(0))
+	if _, ok, _ := s.Get(ctx, "c"); ok {
+		t.Fatal("incomplete entry was applied")
+	}
+	if s.Len() != 2 {
+		t.Fatalf("Len() = %d, want 2", s.Len())
+	}
+	// New entries are written after the last complete one
+	if _, err = s.CompareAndSet(ctx, "d", 0, storetest.Record(cooldown.OpStart, "d")); err != nil {
+		t.Fatal(err)
+	}
+	_ = s.Close()
+
+	s = open(t, dir)
+	if _, ok, _ := s.Get(ctx, "d"); !ok || s.Len() != 3 {
+		t.Fatalf("Len() = %d after recovery, want 3", s.Len())
+	}
+}
+
+func TestCorruptedEntry(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	s := open(t, dir, filestore.OptionCompactEvery(0))
+	for _, key := range []string{"a", "b"} {
+		if _, err := s.CompareAndSet(ctx, key, 0, storetest.Record(cooldown.OpStart, key)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_ = s.Close()
+
+	// Flip the last byte, so checksum of the last entry doesn't match
+	name := filepath.Join(dir, filestore.LogName)
+	b, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b[len(b)-1] ^= 0xff
+	if err = os.WriteFile(name, b, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	s = open(t, dir)
+	if _, ok, _ := s.Get(ctx, "b"); ok || s.Len() != 1 {
+		t.Fatalf("Len() = %d, want 1", s.Len())
+	}
+	_ = s.Close()
+
+	// Zeroed tail is never written, so it is discarded too
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = f.Write(make([]byte, 64))
+	_ = f.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s = open(t, dir)
+	if s.Len() != 1 {
+		t.Fatalf("Len() = %d, want 1", s.Len())
+	}
+	if _, err = s.CompareAndSet(ctx, "c", 0, storetest.Record(cooldown.OpStart, "c")); err != nil {
+		t.Fatal(err)
+	}
+	_ = s.Close()
+
+	// Corruption in the middle of the log is not discarded
+	if b, err = os.ReadFile(name); err != nil {
+		t.Fatal(err)
+	}
+	// Corrupted payload, and corrupted length of the first entry, which
+	// makes it longer than the file.
+	for _, i := range []int{len(b) / 4, 2} {
+		corrupted := slices.Clone(b)
+		corrupted[i] ^= 0x01
+		if err = os.WriteFile(name, corrupted, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err = filestore.Open(dir); !errors.Is(err, filestore.ErrCorrupted) {
+			t.Fatalf("Open with byte %d corrupted = %v, want ErrCorrupted", i, err)
+		}
+	}
+}
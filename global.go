@@ -0,0 +1,197 @@
+package cooldown
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/k4ties/cooldown/internal/event"
+)
+
+// Timer identifies one of the GlobalCoolDown timers.
+type Timer uint8
+
+const (
+	// TimerNone means that no timer is blocking the action.
+	TimerNone Timer = iota
+	// TimerGlobal is the global cooldown shared by all abilities.
+	TimerGlobal
+	// TimerAbility is the individual cooldown of the ability.
+	TimerAbility
+)
+
+// String ...
+func (t Timer) String() string {
+	switch t {
+	case TimerGlobal:
+		return "global"
+	case TimerAbility:
+		return "ability"
+	default:
+		return "none"
+	}
+}
+
+// GlobalCoolDown combines global cooldown (GCD) with individual cooldowns of
+// the abilities. Using any ability starts both the global cooldown and the
+// cooldown of that ability, and ability is ready only if both of them are
+// inactive.
+type GlobalCoolDown[K comparable, T any] struct {
+	mu sync.RWMutex
+
+	gcd       time.Duration
+	global    *Valued[T]
+	abilities map[K]*Valued[T]
+
+	handler atomic.Pointer[GlobalHandler[K, T]]
+}
+
+// NewGlobal creates new GlobalCoolDown with provided global cooldown duration.
+func NewGlobal[K comparable, T any](gcd time.Duration, opts ...GlobalOption[K, T]) *GlobalCoolDown[K, T] {
+	cd := &GlobalCoolDown[K, T]{
+		gcd:       gcd,
+		global:    NewValued[T](),
+		abilities: make(map[K]*Valued[T]),
+	}
+	for _, opt := range opts {
+		if opt == nil {
+			continue
+		}
+		opt(cd)
+	}
+	if cd.handler.Load() == nil {
+		h := GlobalHandler[K, T](NopGlobalHandler[K, T]{})
+		cd.handler.Store(&h)
+	}
+	return cd
+}
+
+// Start starts global cooldown and cooldown of the ability at once. It
+// returns false if ability isn't ready or the use was cancelled by handler.
+func (cooldown *GlobalCoolDown[K, T]) Start(ability K, dur time.Duration, val T) bool {
+	cooldown.mu.Lock()
+	defer cooldown.mu.Unlock()
+	return cooldown.StartUnsafe(ability, dur, val)
+}
+
+func (cooldown *GlobalCoolDown[K, T]) StartUnsafe(ability K, dur time.Duration, val T) bool {
+	cd := cooldown.abilityUnsafe(ability)
+
+	// Both cooldowns are locked for the whole start, so the readers will
+	// never see only one of them started.
+	cooldown.global.mu.Lock()
+	defer cooldown.global.mu.Unlock()
+	cd.mu.Lock()
+	defer cd.mu.Unlock()
+
+	if timer, remaining := blocker(cooldown.global, cd); timer != TimerNone {
+		cooldown.Handler().HandleBlocked(cooldown, ability, timer, remaining, val)
+		return false
+	}
+	ctx := event.C(cooldown)
+	if cooldown.Handler().HandleUse(ctx, ability, dur, val); ctx.Cancelled() {
+		return false
+	}
+	// Both starts are checked before any of them is begun, so cancelled start
+	// of the global cooldown doesn't have to roll back the ability.
+	if dur > 0 && cd.checkStartUnsafe(dur, val) != nil {
+		return false
+	}
+	if cooldown.gcd > 0 && cooldown.global.checkStartUnsafe(cooldown.gcd, val) != nil {
+		return false
+	}
+	if dur > 0 {
+		cd.beginUnsafe(dur, val)
+	}
+	if cooldown.gcd > 0 {
+		cooldown.global.beginUnsafe(cooldown.gcd, val)
+	}
+	return true
+}
+
+// Ready returns true if neither global cooldown nor cooldown of the ability
+// is active.
+func (cooldown *GlobalCoolDown[K, T]) Ready(ability K) bool {
+	timer, _ := cooldown.Blocker(ability)
+	return timer == TimerNone
+}
+
+// Remaining returns effective duration until ability becomes ready.
+func (cooldown *GlobalCoolDown[K, T]) Remaining(ability K) time.Duration {
+	_, remaining := cooldown.Blocker(ability)
+	return remaining
+}
+
+// Blocker returns the timer that currently blocks the ability and duration
+// until it expires. If both timers are active, the one that expires later is
+// returned.
+func (cooldown *GlobalCoolDown[K, T]) Blocker(ability K) (Timer, time.Duration) {
+	cooldown.mu.RLock()
+	defer cooldown.mu.RUnlock()
+
+	cd := cooldown.abilities[ability] // may be nil, if ability was never used
+	cooldown.global.mu.RLock()
+	defer cooldown.global.mu.RUnlock()
+	if cd != nil {
+		cd.mu.RLock()
+		defer cd.mu.RUnlock()
+	}
+	return blocker(cooldown.global, cd)
+}
+
+// blocker returns the timer that blocks the ability. Both cooldowns must be
+// locked by the caller, ability cooldown may be nil.
+func blocker[T any](global, ability *Valued[T]) (Timer, time.Duration) {
+	timer, remaining := TimerNone, time.Duration(0)
+	if global.ActiveUnsafe() {
+		timer, remaining = TimerGlobal, global.RemainingUnsafe()
+	}
+	if ability != nil && ability.ActiveUnsafe() {
+		if r := ability.RemainingUnsafe(); r >= remaining {
+			timer, remaining = TimerAbility, r
+		}
+	}
+	return timer, remaining
+}
+
+// Ability returns individual cooldown of the ability, creating it if it
+// doesn't exist yet.
+func (cooldown *GlobalCoolDown[K, T]) Ability(ability K) *Valued[T] {
+	cooldown.mu.Lock()
+	defer cooldown.mu.Unlock()
+	return cooldown.abilityUnsafe(ability)
+}
+
+func (cooldown *GlobalCoolDown[K, T]) abilityUnsafe(ability K) *Valued[T] {
+	cd, ok := cooldown.abilities[ability]
+	if !ok {
+		cd = NewValued[T]()
+		cooldown.abilities[ability] = cd
+	}
+	return cd
+}
+
+// Global returns the underlying global cooldown.
+func (cooldown *GlobalCoolDown[K, T]) Global() *Valued[T] {
+	return cooldown.global
+}
+
+// GlobalDuration returns duration of the global cooldown.
+func (cooldown *GlobalCoolDown[K, T]) GlobalDuration() time.Duration {
+	cooldown.mu.RLock()
+	defer cooldown.mu.RUnlock()
+	return cooldown.gcd
+}
+
+// Handler ...
+func (cooldown *GlobalCoolDown[K, T]) Handler() GlobalHandler[K, T] {
+	return *cooldown.handler.Load()
+}
+
+// Handle ...
+func (cooldown *GlobalCoolDown[K, T]) Handle(handler GlobalHandler[K, T]) {
+	if handler == nil {
+		handler = NopGlobalHandler[K, T]{}
+	}
+	cooldown.handler.Store(&handler)
+}
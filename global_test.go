@@ -0,0 +1,78 @@
+package cooldown_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+	"github.com/k4ties/cooldown"
+)
+
+type blockedHandler struct {
+	cooldown.NopGlobalHandler[string, struct{}]
+	timer cooldown.Timer
+}
+
+func (h *blockedHandler) HandleBlocked(_ *cooldown.GlobalCoolDown[string, struct{}], _ string, timer cooldown.Timer, _ time.Duration, _ struct{}) {
+	h.timer = timer
+}
+
+func TestGlobal(t *testing.T) {
+	h := new(blockedHandler)
+	g := cooldown.NewGlobal[string, struct{}](time.Millisecond*50, cooldown.GlobalOptionHandler[string, struct{}](h))
+
+	assert.Equal(t, g.Ready("fireball"), true)
+	assert.Equal(t, g.Start("fireball", time.Second, struct{}{}), true)
+	assert.Equal(t, g.Ready("fireball"), false)
+	assert.Equal(t, g.Global().Active(), true)
+	assert.Equal(t, g.Ability("fireball").Active(), true)
+
+	// Other ability is blocked only by the global cooldown
+	timer, remaining := g.Blocker("frostbolt")
+	assert.Equal(t, timer, cooldown.TimerGlobal)
+	assert.Equal(t, remaining > 0 && remaining <= time.Millisecond*50, true)
+	assert.Equal(t, g.Start("frostbolt", time.Second, struct{}{}), false)
+	assert.Equal(t, h.timer, cooldown.TimerGlobal)
+
+	// Ability cooldown is longer, so it is the effective blocker
+	timer, _ = g.Blocker("fireball")
+	assert.Equal(t, timer, cooldown.TimerAbility)
+
+	<-time.After(time.Millisecond * 60)
+	assert.Equal(t, g.Ready("frostbolt"), true)
+	assert.Equal(t, g.Start("fireball", time.Second, struct{}{}), false)
+	assert.Equal(t, h.timer, cooldown.TimerAbility)
+	assert.Equal(t, g.Start("frostbolt", time.Second, struct{}{}), true)
+}
+
+// startRecorder records the starts and stops, and cancels the starts if
+// cancel is true.
+type startRecorder struct {
+	cooldown.NopValuedHandler[struct{}]
+	cancel bool
+	calls  []string
+}
+
+func (h *startRecorder) HandleStart(ctx *cooldown.ValuedContext[struct{}], _ time.Duration, _ struct{}) {
+	h.calls = append(h.calls, "start")
+	if h.cancel {
+		ctx.Cancel()
+	}
+}
+
+func (h *startRecorder) HandleStop(*cooldown.Valued[struct{}], cooldown.StopCause, struct{}) {
+	h.calls = append(h.calls, "stop")
+}
+
+func TestGlobalCancelled(t *testing.T) {
+	g := cooldown.NewGlobal[string, struct{}](time.Second)
+	ability, global := new(startRecorder), &startRecorder{cancel: true}
+	g.Ability("fireball").Handle(ability)
+	g.Global().Handle(global)
+
+	// Ability is not started and stopped if the global start is cancelled.
+	assert.Equal(t, g.Start("fireball", time.Second, struct{}{}), false)
+	assert.Equal(t, g.Ready("fireball"), true)
+	assert.Equal(t, ability.calls, []string{"start"})
+	assert.Equal(t, global.calls, []string{"start"})
+}
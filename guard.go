@@ -0,0 +1,73 @@
+package cooldown
+
+import "time"
+
+// Guard runs fn only if the cooldown is not active. The cooldown is started
+// before fn is called, so concurrent calls can't run fn twice, and is refunded
+// (stopped) if fn returns an error or panics. Returns *ErrOnCooldown if the
+// cooldown is active, ErrStartCancelled if the start was cancelled by handler,
+// or the error returned by fn.
+func Guard(cd *CoolDown, dur time.Duration, fn func() error) error {
+	return GuardValued(cd.valued, dur, zeroStruct, fn)
+}
+
+// GuardValued works like Guard, but for the Valued cooldown. Provided value is
+// used both for the start and for the refund. If the cooldown belongs to the
+// Registry, errors of its constraints are returned as is.
+func GuardValued[T any](cd *Valued[T], dur time.Duration, val T, fn func() error) error {
+	gen, err := cd.tryStart(dur, val)
+	if err != nil {
+		return err
+	}
+	return cd.guard(gen, val, fn)
+}
+
+// GuardKey works like GuardValued, but for the cooldown of the key in the
+// registry. Constraints of the registry are checked before fn is called, and
+// their errors are returned as is.
+func GuardKey[K comparable, T any](reg *Registry[K, T], key K, dur time.Duration, val T, fn func() error) error {
+	reg.mu.Lock()
+	cd, gen, err := reg.tryStartUnsafe(key, dur, val)
+	reg.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return cd.guard(gen, val, fn)
+}
+
+// guard runs fn and refunds the start with provided generation if fn fails.
+func (cooldown *Valued[T]) guard(gen uint64, val T, fn func() error) (err error) {
+	ok := false
+	defer func() {
+		if !ok {
+			cooldown.refund(gen, val)
+		}
+	}()
+	err = fn()
+	ok = err == nil
+	return err
+}
+
+// tryStart works like TryStart, but returns generation of the start, or the
+// reason it was rejected: *ErrOnCooldown or the error of startUnsafe.
+func (cooldown *Valued[T]) tryStart(dur time.Duration, val T) (uint64, error) {
+	defer cooldown.lockStart()()
+	if cooldown.ActiveUnsafe() {
+		cooldown.violateUnsafe()
+		return 0, &ErrOnCooldown{Remaining: cooldown.RemainingUnsafe()}
+	}
+	if err := cooldown.startUnsafe(dur, val); err != nil {
+		return 0, err
+	}
+	return cooldown.gen, nil
+}
+
+// refund stops the cooldown, but only if it wasn't restarted since the start
+// with provided generation. The registry the cooldown belongs to is locked as
+// well, like when it stops the cooldown itself.
+func (cooldown *Valued[T]) refund(gen uint64, val T) {
+	defer cooldown.lockStart()()
+	if cooldown.gen == gen && cooldown.ActiveUnsafe() {
+		cooldown.StopUnsafe(val)
+	}
+}
@@ -0,0 +1,65 @@
+package cooldown_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+	"github.com/k4ties/cooldown"
+)
+
+func TestGuard(t *testing.T) {
+	cd := cooldown.New()
+	errFailed := errors.New("failed")
+
+	// Failed call is refunded
+	assert.Equal(t, cooldown.Guard(cd, time.Second, func() error { return errFailed }), errFailed)
+	assert.Equal(t, cd.Active(), false)
+
+	calls := 0
+	assert.Equal(t, cooldown.Guard(cd, time.Second, func() error {
+		calls++
+		// Cooldown is already started while fn is running
+		var errOnCooldown *cooldown.ErrOnCooldown
+		assert.Equal(t, errors.As(cooldown.Guard(cd, time.Second, func() error { calls++; return nil }), &errOnCooldown), true)
+		return nil
+	}), nil)
+	assert.Equal(t, calls, 1)
+	assert.Equal(t, cd.Active(), true)
+
+	var errOnCooldown *cooldown.ErrOnCooldown
+	assert.Equal(t, errors.As(cooldown.Guard(cd, time.Second, func() error { return nil }), &errOnCooldown), true)
+	assert.Equal(t, errOnCooldown.Remaining > 0, true)
+}
+
+func TestGuardKey(t *testing.T) {
+	reg := cooldown.NewRegistry[string, int]()
+	errFailed := errors.New("failed")
+
+	assert.Equal(t, cooldown.GuardKey(reg, "a", time.Second, 1, func() error { return errFailed }), errFailed)
+	assert.Equal(t, reg.Active("a"), false)
+
+	// Panic is refunded as well
+	func() {
+		defer func() { _ = recover() }()
+		_ = cooldown.GuardKey(reg, "a", time.Second, 1, func() error { panic("boom") })
+	}()
+	assert.Equal(t, reg.Active("a"), false)
+
+	assert.Equal(t, cooldown.GuardKey(reg, "a", time.Second, 1, func() error { return nil }), nil)
+	assert.Equal(t, reg.Active("a"), true)
+}
+
+func TestGuardValuedConstraint(t *testing.T) {
+	reg := cooldown.NewRegistry(cooldown.RegistryOptionConstraint(
+		cooldown.Excludes[string, int]("a", "b"),
+	))
+	assert.Equal(t, reg.Start("b", time.Second, 1), nil)
+
+	// Constraint error of the registry cooldown is returned as is.
+	var cErr *cooldown.ConstraintError[string]
+	err := cooldown.GuardValued(reg.Get("a"), time.Second, 1, func() error { return nil })
+	assert.Equal(t, errors.As(err, &cErr), true)
+	assert.Equal(t, cErr.Other, "b")
+}
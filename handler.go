@@ -29,6 +29,10 @@ type ValuedHandler[T any] interface {
 	// case cooldown.ErrStopCauseCancelled:
 	//    // ...
 	// }
+	//
+	// On expiration the cooldown is already stopped when HandleStop is called,
+	// so it is safe to start it again (via Unsafe methods) from the handler.
+	// Val is the value the cooldown was started with then.
 	HandleStop(cooldown *Valued[T], cause StopCause, val T)
 	// HandlePause handles user pausing the cooldown allowing to cancel event
 	// via context.
@@ -38,6 +42,14 @@ type ValuedHandler[T any] interface {
 	HandleResume(ctx *ValuedContext[T], val T)
 }
 
+// RepeatHandler may be additionally implemented by ValuedHandler to handle
+// cycles of the repeating cooldown started via Valued.StartRepeating.
+type RepeatHandler[T any] interface {
+	// HandleCycle handles end of the cycle with provided index. Val is the
+	// value the cooldown was started with.
+	HandleCycle(cooldown *Valued[T], iteration int, val T)
+}
+
 type Context = event.Context[*CoolDown]
 
 // Handler allows to handle actions with CoolDown, additionally providing a
@@ -88,3 +100,166 @@ func (NopHandler) HandleRenew(*Context, time.Duration) {}
 func (NopHandler) HandleStop(*CoolDown, StopCause)     {}
 func (NopHandler) HandlePause(*Context)                {}
 func (NopHandler) HandleResume(*Context)               {}
+
+type GlobalContext[K comparable, T any] = event.Context[*GlobalCoolDown[K, T]]
+
+// GlobalHandler allows to handle actions with GlobalCoolDown, additionally
+// providing a GlobalContext allowing to cancel the event.
+//
+// Note: you're NOT allowed to call locking GlobalCoolDown methods on handler
+// events, because it is already in lock. Otherwise, it'll cause deadlock.
+type GlobalHandler[K comparable, T any] interface {
+	// HandleUse handles use of the ability allowing user to cancel it via
+	// context. It is called only if the ability is ready.
+	HandleUse(ctx *GlobalContext[K, T], ability K, dur time.Duration, val T)
+	// HandleBlocked handles attempt to use the ability while it is not ready.
+	// Timer is the one that blocked the action, remaining is the duration
+	// until it expires.
+	HandleBlocked(cooldown *GlobalCoolDown[K, T], ability K, timer Timer, remaining time.Duration, val T)
+}
+
+// NopGlobalHandler is no-operation implementation of GlobalHandler.
+type NopGlobalHandler[K comparable, T any] struct{}
+
+func (NopGlobalHandler[K, T]) HandleUse(*GlobalContext[K, T], K, time.Duration, T)             {}
+func (NopGlobalHandler[K, T]) HandleBlocked(*GlobalCoolDown[K, T], K, Timer, time.Duration, T) {}
+
+type PhasedContext[T any] = event.Context[*Phased[T]]
+
+// PhasedHandler allows to handle actions with Phased, additionally providing
+// a PhasedContext allowing to cancel the event.
+//
+// Note: you're NOT allowed to call locking Phased methods on handler events,
+// because it is already in lock. Otherwise, it'll cause deadlock.
+type PhasedHandler[T any] interface {
+	// HandleStart handles start of the cooldown allowing user to cancel it via
+	// context.
+	HandleStart(ctx *PhasedContext[T], val T)
+	// HandlePhase handles transition between phases. From is nil when the
+	// cooldown is started, to is nil when the cooldown is stopped.
+	HandlePhase(cooldown *Phased[T], from, to *Phase, val T)
+	// HandleInterrupt handles interruption of the current phase allowing user
+	// to cancel it via context.
+	HandleInterrupt(ctx *PhasedContext[T], phase Phase, val T)
+	// HandleStop handles stop of the cooldown. Cause is ErrStopCauseExpired if
+	// all phases are passed, ErrStopCauseInterrupted if refunding phase was
+	// interrupted and ErrStopCauseCancelled if the cooldown was stopped.
+	HandleStop(cooldown *Phased[T], cause StopCause, val T)
+	// HandlePause handles user pausing the current phase allowing to cancel
+	// event via context.
+	HandlePause(ctx *PhasedContext[T], phase Phase, val T)
+	// HandleResume handles user resuming the current phase allowing to cancel
+	// event via context.
+	HandleResume(ctx *PhasedContext[T], phase Phase, val T)
+}
+
+// NopPhasedHandler is no-operation implementation of PhasedHandler.
+type NopPhasedHandler[T any] struct{}
+
+func (NopPhasedHandler[T]) HandleStart(*PhasedContext[T], T)            {}
+func (NopPhasedHandler[T]) HandlePhase(*Phased[T], *Phase, *Phase, T)   {}
+func (NopPhasedHandler[T]) HandleInterrupt(*PhasedContext[T], Phase, T) {}
+func (NopPhasedHandler[T]) HandleStop(*Phased[T], StopCause, T)         {}
+func (NopPhasedHandler[T]) HandlePause(*PhasedContext[T], Phase, T)     {}
+func (NopPhasedHandler[T]) HandleResume(*PhasedContext[T], Phase, T)    {}
+
+type SequenceContext[T any] = event.Context[*Sequence[T]]
+
+// SequenceHandler allows to handle actions with Sequence, additionally
+// providing a SequenceContext allowing to cancel the event. Events of the
+// single steps are handled by Step.Handler.
+//
+// Note: you're NOT allowed to call locking Sequence methods on handler events,
+// because it is already in lock. Otherwise, it'll cause deadlock.
+type SequenceHandler[T any] interface {
+	// HandleStep handles transition between steps allowing user to cancel it
+	// via context. From is empty when the sequence is started, cause is the
+	// reason the previous step was stopped. Cancelling the event ends the
+	// sequence.
+	HandleStep(ctx *SequenceContext[T], from, to string, cause StopCause)
+	// HandleStop handles end of the sequence. Cause is ErrStopCauseCancelled
+	// if sequence was cancelled, otherwise it is the stop cause of the last
+	// step.
+	HandleStop(seq *Sequence[T], cause StopCause)
+}
+
+// NopSequenceHandler is no-operation implementation of SequenceHandler.
+type NopSequenceHandler[T any] struct{}
+
+func (NopSequenceHandler[T]) HandleStep(*SequenceContext[T], string, string, StopCause) {}
+func (NopSequenceHandler[T]) HandleStop(*Sequence[T], StopCause)                        {}
+
+type LimiterContext = event.Context[*RateLimiter]
+
+// LimiterHandler allows to handle actions with RateLimiter, additionally
+// providing a LimiterContext allowing to cancel the event.
+//
+// Note: you're NOT allowed to call locking RateLimiter methods on handler
+// events, because it is already in lock. Otherwise, it'll cause deadlock.
+type LimiterHandler interface {
+	// HandleUse handles n uses of the limiter allowing user to cancel it via
+	// context. It is called only if the uses are allowed by the limit.
+	HandleUse(ctx *LimiterContext, n int)
+	// HandleLimitReached handles attempt to use the limiter over the limit.
+	// RetryAfter is the duration until n uses will be allowed.
+	HandleLimitReached(limiter *RateLimiter, n int, retryAfter time.Duration)
+}
+
+// NopLimiterHandler is no-operation implementation of LimiterHandler.
+type NopLimiterHandler struct{}
+
+func (NopLimiterHandler) HandleUse(*LimiterContext, int)                      {}
+func (NopLimiterHandler) HandleLimitReached(*RateLimiter, int, time.Duration) {}
+
+// BackoffHandler allows to handle changes of the Backoff penalty level.
+//
+// Note: you're NOT allowed to call locking Backoff methods on handler events,
+// because it is already in lock. Otherwise, it'll cause deadlock.
+type BackoffHandler[T any] interface {
+	// HandleLevel handles change of the penalty level. Level is increased by
+	// violations and decreased by decay or reset.
+	HandleLevel(cooldown *Backoff[T], from, to int, val T)
+}
+
+// NopBackoffHandler is no-operation implementation of BackoffHandler.
+type NopBackoffHandler[T any] struct{}
+
+func (NopBackoffHandler[T]) HandleLevel(*Backoff[T], int, int, T) {}
+
+type QuotaContext = event.Context[*Quota]
+
+// QuotaHandler allows to handle actions with Quota, additionally providing a
+// QuotaContext allowing to cancel the event.
+//
+// Note: you're NOT allowed to call locking Quota methods on handler events,
+// because it is already in lock. Otherwise, it'll cause deadlock.
+type QuotaHandler interface {
+	// HandleUse handles n uses of the quota allowing user to cancel it via
+	// context. It is called only if there are enough uses left.
+	HandleUse(ctx *QuotaContext, n int)
+	// HandleReset handles reset of the quota. At is the scheduled reset time.
+	HandleReset(quota *Quota, at time.Time)
+}
+
+// NopQuotaHandler is no-operation implementation of QuotaHandler.
+type NopQuotaHandler struct{}
+
+func (NopQuotaHandler) HandleUse(*QuotaContext, int)  {}
+func (NopQuotaHandler) HandleReset(*Quota, time.Time) {}
+
+// ViolationHandler allows to handle violations recorded by the
+// ViolationRecorder.
+//
+// Note: you're NOT allowed to call locking ViolationRecorder methods on
+// handler events, because it is already in lock. Otherwise, it'll cause
+// deadlock.
+type ViolationHandler[K comparable] interface {
+	// HandleViolation handles count of the violations of the key reaching the
+	// threshold.
+	HandleViolation(recorder *ViolationRecorder[K], key K, threshold int, stats ViolationStats)
+}
+
+// NopViolationHandler is no-operation implementation of ViolationHandler.
+type NopViolationHandler[K comparable] struct{}
+
+func (NopViolationHandler[K]) HandleViolation(*ViolationRecorder[K], K, int, ViolationStats) {}
@@ -0,0 +1,211 @@
+// Package resp implements minimal encoding and decoding of the Redis
+// serialization protocol (RESP2).
+package resp
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// maxBulkSize limits size of the single bulk string.
+const maxBulkSize = 512 << 20
+
+// ErrProtocol is returned when the peer sends malformed data.
+var ErrProtocol = errors.New("resp: protocol error")
+
+// Kind is the type of the Value.
+type Kind byte
+
+const (
+	SimpleString Kind = '+'
+	Error        Kind = '-'
+	Integer      Kind = ':'
+	BulkString   Kind = '$'
+	Array        Kind = '*'
+)
+
+// Value is the decoded RESP value.
+type Value struct {
+	Kind Kind
+	// Str is the content of simple strings, errors and bulk strings.
+	Str []byte
+	// Int is the value of integers.
+	Int int64
+	// Array contains elements of arrays.
+	Array []Value
+	// Nil is true for null bulk strings and null arrays.
+	Nil bool
+}
+
+// Err returns the error of the error value, or nil.
+func (v Value) Err() error {
+	if v.Kind != Error {
+		return nil
+	}
+	return ServerError(v.Str)
+}
+
+// ServerError is the error returned by the server.
+type ServerError string
+
+func (err ServerError) Error() string {
+	return string(err)
+}
+
+// Reader reads RESP values.
+type Reader struct {
+	r *bufio.Reader
+}
+
+// NewReader creates new Reader.
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: bufio.NewReader(r)}
+}
+
+// Read reads the next value.
+func (r *Reader) Read() (Value, error) {
+	line, err := r.line()
+	if err != nil {
+		return Value{}, err
+	}
+	if len(line) == 0 {
+		return Value{}, ErrProtocol
+	}
+	v := Value{Kind: Kind(line[0])}
+	switch v.Kind {
+	case SimpleString, Error:
+		v.Str = line[1:]
+	case Integer:
+		v.Int, err = strconv.ParseInt(string(line[1:]), 10, 64)
+	case BulkString:
+		var n int64
+		if n, err = strconv.ParseInt(string(line[1:]), 10, 64); err != nil || n < 0 {
+			v.Nil = n < 0
+			break
+		}
+		if n > maxBulkSize {
+			return Value{}, ErrProtocol
+		}
+		v.Str = make([]byte, n+2)
+		if _, err = io.ReadFull(r.r, v.Str); err == nil {
+			if v.Str[n] != '\r' || v.Str[n+1] != '\n' {
+				return Value{}, ErrProtocol
+			}
+			v.Str = v.Str[:n]
+		}
+	case Array:
+		var n int64
+		if n, err = strconv.ParseInt(string(line[1:]), 10, 64); err != nil || n < 0 {
+			v.Nil = n < 0
+			break
+		}
+		v.Array = make([]Value, 0, min(n, 1024))
+		for range n {
+			elem, err := r.Read()
+			if err != nil {
+				return Value{}, err
+			}
+			v.Array = append(v.Array, elem)
+		}
+	default:
+		return Value{}, ErrProtocol
+	}
+	if err != nil {
+		if errors.Is(err, strconv.ErrSyntax) || errors.Is(err, strconv.ErrRange) {
+			return Value{}, ErrProtocol
+		}
+		return Value{}, err
+	}
+	return v, nil
+}
+
+// line reads the line without the trailing CRLF.
+func (r *Reader) line() ([]byte, error) {
+	line, err := r.r.ReadSlice('\n')
+	if err != nil {
+		if errors.Is(err, bufio.ErrBufferFull) {
+			return nil, ErrProtocol
+		}
+		return nil, err
+	}
+	if len(line) < 2 || line[len(line)-2] != '\r' {
+		return nil, ErrProtocol
+	}
+	return append([]byte(nil), line[:len(line)-2]...), nil
+}
+
+// Writer writes RESP values. Values are buffered until Flush is called.
+type Writer struct {
+	w *bufio.Writer
+}
+
+// NewWriter creates new Writer.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: bufio.NewWriter(w)}
+}
+
+// Command writes the command as array of bulk strings. Arguments may be
+// strings, byte slices or integers.
+func (w *Writer) Command(args ...any) {
+	w.ArrayHeader(len(args))
+	for _, arg := range args {
+		switch arg := arg.(type) {
+		case string:
+			w.Bulk([]byte(arg))
+		case []byte:
+			w.Bulk(arg)
+		case int:
+			w.Bulk(strconv.AppendInt(nil, int64(arg), 10))
+		case int64:
+			w.Bulk(strconv.AppendInt(nil, arg, 10))
+		default:
+			w.Bulk(fmt.Append(nil, arg))
+		}
+	}
+}
+
+// Simple writes simple string.
+func (w *Writer) Simple(s string) {
+	_, _ = fmt.Fprintf(w.w, "+%s\r\n", s)
+}
+
+// Error writes error.
+func (w *Writer) Error(s string) {
+	_, _ = fmt.Fprintf(w.w, "-%s\r\n", s)
+}
+
+// Int writes integer.
+func (w *Writer) Int(n int64) {
+	_, _ = fmt.Fprintf(w.w, ":%d\r\n", n)
+}
+
+// Bulk writes bulk string.
+func (w *Writer) Bulk(b []byte) {
+	_, _ = fmt.Fprintf(w.w, "$%d\r\n", len(b))
+	_, _ = w.w.Write(b)
+	_, _ = w.w.WriteString("\r\n")
+}
+
+// Nil writes null bulk string.
+func (w *Writer) Nil() {
+	_, _ = w.w.WriteString("$-1\r\n")
+}
+
+// NilArray writes null array.
+func (w *Writer) NilArray() {
+	_, _ = w.w.WriteString("*-1\r\n")
+}
+
+// ArrayHeader writes header of the array with n elements, which must be
+// written next.
+func (w *Writer) ArrayHeader(n int) {
+	_, _ = fmt.Fprintf(w.w, "*%d\r\n", n)
+}
+
+// Flush writes buffered values.
+func (w *Writer) Flush() error {
+	return w.w.Flush()
+}
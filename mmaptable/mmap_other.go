@@ -0,0 +1,11 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd || dragonfly)
+
+package mmaptable
+
+import "errors"
+
+// Open is not supported on this platform, it always returns
+// errors.ErrUnsupported.
+func Open(string, Config) (*Table, error) {
+	return nil, errors.ErrUnsupported
+}
@@ -0,0 +1,75 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+
+package mmaptable
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"syscall"
+)
+
+// Open opens the table in provided file, creating and initializing it if it
+// doesn't exist. Zero fields of the config are replaced with DefaultConfig.
+// ErrIncompatible is returned if the file was initialized with another config.
+func Open(name string, conf Config) (*Table, error) {
+	conf = conf.normalize()
+	f, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	// The file is needed only to map it, the mapping is kept after it is
+	// closed.
+	defer f.Close()
+
+	if err = initialize(f, conf); err != nil {
+		return nil, err
+	}
+	data, err := syscall.Mmap(int(f.Fd()), 0, int(conf.size()), syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, err
+	}
+	return newTable(data, conf, func() error {
+		return syscall.Munmap(data)
+	}), nil
+}
+
+// initialize writes the header and resizes the file if it is empty, or
+// validates its header, holding exclusive lock of the file.
+func initialize(f *os.File, conf Config) (err error) {
+	if err = syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Join(err, syscall.Flock(int(f.Fd()), syscall.LOCK_UN))
+	}()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	header, existing := conf.header(), make([]byte, headerSize)
+	if info.Size() >= headerSize {
+		if _, err = f.ReadAt(existing, 0); err != nil {
+			return err
+		}
+	}
+	if bytes.Equal(existing, make([]byte, headerSize)) {
+		// The file is new, or its initialization was interrupted. Header is
+		// written the last, so such files are always detected.
+		if err = f.Truncate(0); err != nil {
+			return err
+		}
+		if err = f.Truncate(conf.size()); err != nil {
+			return err
+		}
+		if _, err = f.WriteAt(header, 0); err != nil {
+			return err
+		}
+		return f.Sync()
+	}
+	if !bytes.Equal(existing, header) || info.Size() != conf.size() {
+		return ErrIncompatible
+	}
+	return nil
+}
@@ -0,0 +1,446 @@
+// Package mmaptable implements fixed-size hash table of cooldowns in the
+// memory-mapped file, so several processes on the same host can share them
+// without any server.
+//
+// Every slot of the table contains the key and a single 64-bit state word,
+// which is changed only with atomic compare-and-swap, so processes never block
+// each other. The state word is zero if the cooldown is inactive, the
+// expiration time in Unix nanoseconds if it is running, and the negated
+// remaining duration if it is paused. Since expirations are wall clock times,
+// all the processes must use the same clock, which is true on the same host.
+//
+// Slots are claimed on the first start of the key and are never freed, so the
+// table must be sized for all the keys it will ever contain. If the process
+// dies while claiming the slot, other processes free it after claimTimeout.
+// The file is initialized under an exclusive file lock, so processes may open
+// it concurrently.
+package mmaptable
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/fnv"
+	"sync/atomic"
+	"time"
+	"unsafe"
+
+	"github.com/k4ties/cooldown"
+)
+
+var (
+	// ErrTableFull is returned when there is no free slot for the new key.
+	ErrTableFull = errors.New("mmaptable: table is full")
+	// ErrKeyTooLong is returned when the key doesn't fit into the slot.
+	ErrKeyTooLong = errors.New("mmaptable: key is too long")
+	// ErrIncompatible is returned when the file has different layout than
+	// requested, or is not a table at all.
+	ErrIncompatible = errors.New("mmaptable: incompatible file")
+)
+
+const (
+	magic         = "CDMMAP01"
+	headerSize    = 64
+	slotFixedSize = 32
+)
+
+// Slot tags. The lower bits of the tag word are the tag, and the upper bits
+// are the generation, which is increased every time an abandoned claim is
+// freed, so the stale claim can't be freed twice.
+const (
+	tagEmpty uint32 = iota
+	// tagClaiming is set while the key is written into the slot.
+	tagClaiming
+	tagReady
+
+	tagMask uint32 = 3
+	tagGen  uint32 = 4
+)
+
+// claimTimeout is how long the slot may stay claimed before other processes
+// consider the claiming process dead and free the slot.
+const claimTimeout = time.Second
+
+// maxClaimBackoff is the longest sleep between the checks of the claimed slot.
+const maxClaimBackoff = time.Millisecond
+
+// Config is the layout of the table. Processes sharing the file must use the
+// same config.
+type Config struct {
+	// Slots is the count of the keys the table can contain.
+	Slots int
+	// KeySize is the maximum length of the key in bytes. It is rounded up to
+	// multiple of 8.
+	KeySize int
+}
+
+// DefaultConfig is used for zero fields of the config.
+var DefaultConfig = Config{Slots: 4096, KeySize: 56}
+
+// Table is the table of cooldowns in the memory-mapped file.
+type Table struct {
+	data     []byte
+	slots    int
+	keySize  int
+	slotSize int
+	close    func() error
+}
+
+// newTable creates table over the mapped data with validated header.
+func newTable(data []byte, conf Config, close func() error) *Table {
+	return &Table{
+		data:     data,
+		slots:    conf.Slots,
+		keySize:  conf.KeySize,
+		slotSize: slotFixedSize + conf.KeySize,
+		close:    close,
+	}
+}
+
+func (conf Config) normalize() Config {
+	if conf.Slots <= 0 {
+		conf.Slots = DefaultConfig.Slots
+	}
+	if conf.KeySize <= 0 {
+		conf.KeySize = DefaultConfig.KeySize
+	}
+	conf.KeySize = (conf.KeySize + 7) &^ 7
+	return conf
+}
+
+func (conf Config) size() int64 {
+	return headerSize + int64(conf.Slots)*int64(slotFixedSize+conf.KeySize)
+}
+
+// header encodes the header of the file with provided config.
+func (conf Config) header() []byte {
+	b := make([]byte, headerSize)
+	copy(b, magic)
+	binary.LittleEndian.PutUint64(b[8:], uint64(conf.Slots))
+	binary.LittleEndian.PutUint64(b[16:], uint64(conf.KeySize))
+	return b
+}
+
+// slot is the view of the single slot of the table.
+type slot struct {
+	tag, keyLen *uint32
+	// state is the state word, see the package documentation.
+	state,
+	// duration is the duration the cooldown was last started with.
+	duration,
+	// pausedAt is the time the cooldown was last paused at.
+	pausedAt *int64
+	key []byte
+}
+
+func (t *Table) slot(i int) slot {
+	off := headerSize + i*t.slotSize
+	b := t.data[off : off+t.slotSize]
+	return slot{
+		tag:      (*uint32)(unsafe.Pointer(&b[0])),
+		keyLen:   (*uint32)(unsafe.Pointer(&b[4])),
+		state:    (*int64)(unsafe.Pointer(&b[8])),
+		duration: (*int64)(unsafe.Pointer(&b[16])),
+		pausedAt: (*int64)(unsafe.Pointer(&b[24])),
+		key:      b[slotFixedSize:],
+	}
+}
+
+// waitReady waits until the key of the slot is written by another process.
+// Returns false if the slot is empty. If the slot stays claimed for
+// claimTimeout, the claim is considered abandoned and the slot is freed.
+func (s slot) waitReady() bool {
+	var (
+		claim    uint32
+		deadline time.Time
+		backoff  time.Duration
+	)
+	for {
+		tag := atomic.LoadUint32(s.tag)
+		switch tag & tagMask {
+		case tagEmpty:
+			return false
+		case tagReady:
+			return true
+		}
+		if now := time.Now(); tag != claim {
+			claim, deadline, backoff = tag, now.Add(claimTimeout), 0
+		} else if now.After(deadline) {
+			// Fails if the slot was already freed or claimed again.
+			atomic.CompareAndSwapUint32(s.tag, tag, tag&^tagMask+tagGen)
+			continue
+		}
+		// The key is usually written right away, so the wait starts short and
+		// grows up to maxClaimBackoff while the claim is held.
+		time.Sleep(backoff)
+		backoff = min(max(backoff*2, time.Microsecond), maxClaimBackoff)
+	}
+}
+
+// ready returns true if the key of the slot is written.
+func (s slot) ready() bool {
+	return atomic.LoadUint32(s.tag)&tagMask == tagReady
+}
+
+// writeKey writes the key into the slot claimed with provided tag and marks it
+// ready. Returns false if the claim was freed by another process. The key is
+// written only while the claim is still held, and is verified after the slot
+// is marked ready, since a stale claimer that stalled past claimTimeout may
+// still overwrite it.
+func (s slot) writeKey(key string, claim uint32) bool {
+	if atomic.LoadUint32(s.tag) != claim {
+		return false
+	}
+	copy(s.key, key)
+	if atomic.LoadUint32(s.tag) != claim {
+		return false
+	}
+	atomic.StoreUint32(s.keyLen, uint32(len(key)))
+	if !atomic.CompareAndSwapUint32(s.tag, claim, claim&^tagMask|tagReady) {
+		return false
+	}
+	// The slot is ours now, so the key torn by the stale claimer is repaired.
+	for !s.is(key) {
+		copy(s.key, key)
+		atomic.StoreUint32(s.keyLen, uint32(len(key)))
+	}
+	return true
+}
+
+func (s slot) is(key string) bool {
+	n := atomic.LoadUint32(s.keyLen)
+	return int(n) == len(key) && string(s.key[:n]) == key
+}
+
+// find returns the slot of the key. If create is true, the slot is claimed if
+// the key is not in the table yet.
+func (t *Table) find(key string, create bool) (slot, error) {
+	if len(key) > t.keySize {
+		return slot{}, ErrKeyTooLong
+	}
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(key))
+	start := int(h.Sum64() % uint64(t.slots))
+	for i := 0; i < t.slots; {
+		s := t.slot((start + i) % t.slots)
+		if s.waitReady() {
+			if s.is(key) {
+				return s, nil
+			}
+			i++
+			continue
+		}
+		if !create {
+			return slot{}, nil
+		}
+		// Empty tag has zero lower bits, so the generation is kept.
+		tag := atomic.LoadUint32(s.tag)
+		if tag&tagMask != tagEmpty || !atomic.CompareAndSwapUint32(s.tag, tag, tag|tagClaiming) {
+			// Claimed by another process, check the slot again.
+			continue
+		}
+		if s.writeKey(key, tag|tagClaiming) {
+			return s, nil
+		}
+		// The claim took too long and the slot was freed by another process,
+		// check it again.
+	}
+	if !create {
+		return slot{}, nil
+	}
+	return slot{}, ErrTableFull
+}
+
+// TryStart starts cooldown of the key only if it is not active. Returns
+// *cooldown.ErrOnCooldown if it is.
+func (t *Table) TryStart(key string, dur time.Duration) error {
+	if dur <= 0 {
+		return cooldown.ErrStartCancelled
+	}
+	s, err := t.find(key, true)
+	if err != nil {
+		return err
+	}
+	for {
+		cur, now := atomic.LoadInt64(s.state), time.Now().UnixNano()
+		if remaining := remaining(cur, now); remaining > 0 {
+			return &cooldown.ErrOnCooldown{Remaining: remaining}
+		}
+		if atomic.CompareAndSwapInt64(s.state, cur, now+int64(dur)) {
+			atomic.StoreInt64(s.duration, int64(dur))
+			return nil
+		}
+	}
+}
+
+// Start starts cooldown of the key, even if it is active.
+func (t *Table) Start(key string, dur time.Duration) error {
+	if dur <= 0 {
+		return cooldown.ErrStartCancelled
+	}
+	s, err := t.find(key, true)
+	if err != nil {
+		return err
+	}
+	atomic.StoreInt64(s.state, time.Now().UnixNano()+int64(dur))
+	atomic.StoreInt64(s.duration, int64(dur))
+	return nil
+}
+
+// Renew restarts running cooldown of the key with the duration it was last
+// started with. Returns false if it is not running.
+func (t *Table) Renew(key string) bool {
+	_, ok := t.update(key, func(cur, now int64, s slot) (int64, bool) {
+		if cur <= now {
+			return 0, false
+		}
+		return now + atomic.LoadInt64(s.duration), true
+	})
+	return ok
+}
+
+// Pause pauses running cooldown of the key. Returns false if it is not
+// running.
+func (t *Table) Pause(key string) bool {
+	var pausedAt int64
+	s, ok := t.update(key, func(cur, now int64, _ slot) (int64, bool) {
+		if cur <= now {
+			return 0, false
+		}
+		pausedAt = now
+		return now - cur, true
+	})
+	if ok {
+		// Only the process whose pause succeeded stores its time.
+		atomic.StoreInt64(s.pausedAt, pausedAt)
+	}
+	return ok
+}
+
+// Resume resumes paused cooldown of the key. Returns false if it is not
+// paused.
+func (t *Table) Resume(key string) bool {
+	_, ok := t.update(key, func(cur, now int64, _ slot) (int64, bool) {
+		if cur >= 0 {
+			return 0, false
+		}
+		return now - cur, true
+	})
+	return ok
+}
+
+// Stop stops cooldown of the key. Returns false if it is not active.
+func (t *Table) Stop(key string) bool {
+	_, ok := t.update(key, func(cur, now int64, _ slot) (int64, bool) {
+		if remaining(cur, now) <= 0 {
+			return 0, false
+		}
+		return 0, true
+	})
+	return ok
+}
+
+// update changes the state word of the key with fn until it succeeds. Returns
+// the slot of the key and true if the state word was changed.
+func (t *Table) update(key string, fn func(cur, now int64, s slot) (int64, bool)) (slot, bool) {
+	s, err := t.find(key, false)
+	if err != nil || s.state == nil {
+		return slot{}, false
+	}
+	for {
+		cur := atomic.LoadInt64(s.state)
+		next, ok := fn(cur, time.Now().UnixNano(), s)
+		if !ok {
+			return slot{}, false
+		}
+		if atomic.CompareAndSwapInt64(s.state, cur, next) {
+			return s, true
+		}
+	}
+}
+
+// Active returns true if cooldown of the key is active.
+func (t *Table) Active(key string) bool {
+	return t.Remaining(key) > 0
+}
+
+// Remaining returns duration until cooldown of the key expires.
+func (t *Table) Remaining(key string) time.Duration {
+	s, err := t.find(key, false)
+	if err != nil || s.state == nil {
+		return 0
+	}
+	return remaining(atomic.LoadInt64(s.state), time.Now().UnixNano())
+}
+
+// State returns state of the cooldown of the key.
+func (t *Table) State(key string) cooldown.BasicState {
+	s, err := t.find(key, false)
+	if err != nil || s.state == nil {
+		return cooldown.BasicState{}
+	}
+	return s.basicState()
+}
+
+func (s slot) basicState() (state cooldown.BasicState) {
+	now := time.Now()
+	cur := atomic.LoadInt64(s.state)
+	switch {
+	case cur < 0:
+		pausedAt := time.Unix(0, atomic.LoadInt64(s.pausedAt))
+		state.Active, state.Paused = true, true
+		state.PausedDate, state.Expiration = pausedAt, pausedAt.Add(time.Duration(-cur))
+	case cur > now.UnixNano():
+		state.Active, state.Expiration = true, time.Unix(0, cur)
+	}
+	return state
+}
+
+// Len returns count of the keys in the table.
+func (t *Table) Len() int {
+	n := 0
+	for i := range t.slots {
+		if t.slot(i).ready() {
+			n++
+		}
+	}
+	return n
+}
+
+// Cap returns count of the keys the table can contain.
+func (t *Table) Cap() int {
+	return t.slots
+}
+
+// Range calls fn for every key in the table, until it returns false.
+func (t *Table) Range(fn func(key string, state cooldown.BasicState) bool) {
+	for i := range t.slots {
+		s := t.slot(i)
+		if !s.ready() {
+			continue
+		}
+		if !fn(string(s.key[:atomic.LoadUint32(s.keyLen)]), s.basicState()) {
+			return
+		}
+	}
+}
+
+// Close unmaps the file. The table must not be used after it is closed.
+func (t *Table) Close() error {
+	if t.close == nil {
+		return nil
+	}
+	err := t.close()
+	t.close, t.data = nil, nil
+	return err
+}
+
+// remaining returns remaining duration of the state word at provided time.
+func remaining(state, now int64) time.Duration {
+	switch {
+	case state < 0:
+		return time.Duration(-state)
+	case state > now:
+		return time.Duration(state - now)
+	}
+	return 0
+}
@@ -0,0 +1,245 @@
+package mmaptable_test
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/k4ties/cooldown"
+	"github.com/k4ties/cooldown/mmaptable"
+)
+
+// Environment variables of the helper processes.
+const (
+	envFile = "MMAPTABLE_FILE"
+	envMode = "MMAPTABLE_MODE"
+	envAt   = "MMAPTABLE_AT"
+)
+
+var conf = mmaptable.Config{Slots: 256, KeySize: 16}
+
+func TestMain(m *testing.M) {
+	if name := os.Getenv(envFile); name != "" {
+		if err := helper(name, os.Getenv(envMode)); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// helper runs in the helper process and prints its result to stdout.
+func helper(name, mode string) error {
+	if at, err := strconv.ParseInt(os.Getenv(envAt), 10, 64); err == nil {
+		// Start all the helpers at the same time to maximize contention.
+		time.Sleep(time.Until(time.Unix(0, at)))
+	}
+	t, err := mmaptable.Open(name, conf)
+	if err != nil {
+		return err
+	}
+	defer t.Close()
+
+	switch mode {
+	case "start":
+		started := 0
+		for i := range 100 {
+			if t.TryStart(fmt.Sprintf("key-%d", i), time.Hour) == nil {
+				started++
+			}
+		}
+		if t.TryStart("shared", time.Hour) == nil {
+			started++
+		}
+		fmt.Print(started)
+	case "pause":
+		fmt.Print(t.Pause("shared"))
+	default:
+		return fmt.Errorf("unknown mode %q", mode)
+	}
+	return nil
+}
+
+// spawn starts the helper process with provided mode.
+func spawn(t *testing.T, name, mode string, at time.Time) *exec.Cmd {
+	t.Helper()
+	cmd := exec.Command(os.Args[0], "-test.run=^$")
+	cmd.Env = append(os.Environ(),
+		envFile+"="+name,
+		envMode+"="+mode,
+		envAt+"="+strconv.FormatInt(at.UnixNano(), 10),
+	)
+	cmd.Stderr = os.Stderr
+	return cmd
+}
+
+func open(t *testing.T, name string, c mmaptable.Config) *mmaptable.Table {
+	t.Helper()
+	table, err := mmaptable.Open(name, c)
+	if errors.Is(err, errors.ErrUnsupported) {
+		t.Skip(err)
+	}
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	t.Cleanup(func() { _ = table.Close() })
+	return table
+}
+
+func TestTable(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "table")
+	table := open(t, name, conf)
+
+	if err := table.TryStart("a", time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	var onCooldown *cooldown.ErrOnCooldown
+	if err := table.TryStart("a", time.Hour); !errors.As(err, &onCooldown) {
+		t.Fatalf("TryStart(active) = %v, want *ErrOnCooldown", err)
+	}
+	if !table.Pause("a") || table.Pause("a") {
+		t.Fatal("Pause must succeed only once")
+	}
+	state := table.State("a")
+	if !state.Active || !state.Paused || state.Expiration.Sub(state.PausedDate) != table.Remaining("a") {
+		t.Fatalf("paused state = %+v", state)
+	}
+	time.Sleep(time.Millisecond * 10)
+	if !table.Resume("a") || table.Remaining("a") < time.Hour-time.Millisecond*5 {
+		t.Fatalf("time in pause was counted, remaining = %s", table.Remaining("a"))
+	}
+	if !table.Renew("a") || !table.Stop("a") || table.Active("a") || table.Stop("a") {
+		t.Fatal("Renew and Stop must succeed only on active cooldown")
+	}
+	if table.Active("missing") || table.Pause("missing") {
+		t.Fatal("missing key must be inactive")
+	}
+
+	if err := table.Start(strings.Repeat("k", 17), time.Hour); !errors.Is(err, mmaptable.ErrKeyTooLong) {
+		t.Fatalf("Start(long key) = %v, want ErrKeyTooLong", err)
+	}
+	if table.Len() != 1 || table.Cap() != 256 {
+		t.Fatalf("Len() = %d, Cap() = %d", table.Len(), table.Cap())
+	}
+
+	if _, err := mmaptable.Open(name, mmaptable.Config{Slots: 128, KeySize: 16}); !errors.Is(err, mmaptable.ErrIncompatible) {
+		t.Fatalf("Open(other config) = %v, want ErrIncompatible", err)
+	}
+}
+
+func TestTableFull(t *testing.T) {
+	table := open(t, filepath.Join(t.TempDir(), "table"), mmaptable.Config{Slots: 2})
+	for _, key := range []string{"a", "b"} {
+		if err := table.Start(key, time.Hour); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := table.Start("c", time.Hour); !errors.Is(err, mmaptable.ErrTableFull) {
+		t.Fatalf("Start(c) = %v, want ErrTableFull", err)
+	}
+	// Existing keys are still found
+	if err := table.Start("b", time.Hour); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMultiProcess(t *testing.T) {
+	const processes = 4
+	dir := t.TempDir()
+	// Helpers fail if the platform is not supported, so check it first.
+	open(t, filepath.Join(dir, "probe"), conf)
+	name := filepath.Join(dir, "table")
+	// Every process opens the new file at the same time, so the
+	// initialization is also tested.
+	at := time.Now().Add(time.Millisecond * 500)
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		started int
+	)
+	for range processes {
+		cmd := spawn(t, name, "start", at)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			out, err := cmd.Output()
+			if err != nil {
+				t.Errorf("helper failed: %v", err)
+				return
+			}
+			n, err := strconv.Atoi(string(out))
+			if err != nil {
+				t.Errorf("unexpected helper output %q", out)
+				return
+			}
+			mu.Lock()
+			started += n
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	// Every key must be started exactly once across all the processes.
+	if started != 101 {
+		t.Fatalf("started %d cooldowns, want 101", started)
+	}
+	table := open(t, name, conf)
+	if table.Len() != 101 || !table.Active("shared") || !table.Active("key-99") {
+		t.Fatalf("Len() = %d, want 101 active keys", table.Len())
+	}
+}
+
+func TestAbandonedClaim(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "table")
+	table := open(t, name, conf)
+
+	// Simulate the process which died while claiming the slot of the key.
+	h := fnv.New64a()
+	_, _ = h.Write([]byte("dead"))
+	off := 64 + int64(h.Sum64()%uint64(conf.Slots))*int64(32+conf.KeySize)
+	f, err := os.OpenFile(name, os.O_RDWR, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = f.WriteAt([]byte{1, 0, 0, 0}, off)
+	_ = f.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := table.Start("dead", time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if table.Len() != 1 || !table.Active("dead") {
+		t.Fatalf("Len() = %d, abandoned slot must be claimed again", table.Len())
+	}
+}
+
+func TestCrossProcessPause(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "table")
+	table := open(t, name, conf)
+	if err := table.Start("shared", time.Hour); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := spawn(t, name, "pause", time.Now()).Output()
+	if err != nil || string(out) != "true" {
+		t.Fatalf("helper = %q, %v, want true", out, err)
+	}
+	if !table.State("shared").Paused {
+		t.Fatal("pause of another process is not visible")
+	}
+	if !table.Resume("shared") {
+		t.Fatal("cooldown paused by another process can't be resumed")
+	}
+}
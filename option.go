@@ -1,10 +1,45 @@
 package cooldown
 
+import (
+	"slices"
+	"time"
+)
+
 type (
+	// BasicOption is option implementation for the Basic cooldown.
+	BasicOption = func(cd *Basic)
 	// ValuedOption is option implementation for the Valued cooldown.
 	ValuedOption[T any] = func(cd *Valued[T])
 	// Option is the option implementation for default CoolDown.
 	Option = func(cd *CoolDown)
+	// GlobalOption is the option implementation for the GlobalCoolDown.
+	GlobalOption[K comparable, T any] = func(cd *GlobalCoolDown[K, T])
+	// PhasedOption is the option implementation for the Phased cooldown.
+	PhasedOption[T any] = func(cd *Phased[T])
+	// SequenceOption is the option implementation for the Sequence.
+	SequenceOption[T any] = func(seq *Sequence[T])
+	// RegistryOption is the option implementation for the Registry.
+	RegistryOption[K comparable, T any] = func(reg *Registry[K, T])
+	// LimiterOption is the option implementation for the RateLimiter.
+	LimiterOption = func(l *RateLimiter)
+	// BucketOption is the option implementation for the Bucket.
+	BucketOption = func(b *Bucket)
+	// BackoffOption is the option implementation for the Backoff cooldown.
+	BackoffOption[T any] = func(cd *Backoff[T])
+	// DiminishingOption is the option implementation for the Diminishing
+	// tracker.
+	DiminishingOption[K, C comparable] = func(d *Diminishing[K, C])
+	// QuotaOption is the option implementation for the Quota.
+	QuotaOption = func(q *Quota)
+	// ViolationOption is the option implementation for the ViolationRecorder.
+	ViolationOption[K comparable] = func(r *ViolationRecorder[K])
+	// ScopedOption is the option implementation for the Scoped cooldown
+	// manager.
+	ScopedOption[I comparable, T any] = func(s *Scoped[I, T])
+	// StoredOption is the option implementation for the StoredRegistry.
+	StoredOption[T any] = func(reg *StoredRegistry[T])
+	// TokenOption is the option implementation for the Tokens.
+	TokenOption = func(t *Tokens)
 )
 
 func ValuedOptionHandler[T any](h ValuedHandler[T]) ValuedOption[T] {
@@ -18,3 +53,244 @@ func OptionHandler(h Handler) Option {
 		cd.Handle(h)
 	}
 }
+
+// BasicOptionClock sets the clock used by the Basic cooldown.
+func BasicOptionClock(c Clock) BasicOption {
+	return func(cd *Basic) {
+		cd.clock = c
+	}
+}
+
+// ValuedOptionClock sets the clock used by the Valued cooldown, both for its
+// state and for the expiration timer.
+func ValuedOptionClock[T any](c Clock) ValuedOption[T] {
+	return func(cd *Valued[T]) {
+		cd.basic.clock = c
+	}
+}
+
+// OptionClock sets the clock used by the CoolDown.
+func OptionClock(c Clock) Option {
+	return func(cd *CoolDown) {
+		cd.valued.basic.clock = c
+	}
+}
+
+func GlobalOptionHandler[K comparable, T any](h GlobalHandler[K, T]) GlobalOption[K, T] {
+	return func(cd *GlobalCoolDown[K, T]) {
+		cd.Handle(h)
+	}
+}
+
+func PhasedOptionHandler[T any](h PhasedHandler[T]) PhasedOption[T] {
+	return func(cd *Phased[T]) {
+		cd.Handle(h)
+	}
+}
+
+func SequenceOptionHandler[T any](h SequenceHandler[T]) SequenceOption[T] {
+	return func(seq *Sequence[T]) {
+		seq.Handle(h)
+	}
+}
+
+// RegistryOptionValued sets options applied to every cooldown created by the
+// Registry.
+func RegistryOptionValued[K comparable, T any](opts ...ValuedOption[T]) RegistryOption[K, T] {
+	return func(reg *Registry[K, T]) {
+		reg.opts = append(reg.opts, opts...)
+	}
+}
+
+// RegistryOptionConstraint registers constraints checked by the Registry.
+func RegistryOptionConstraint[K comparable, T any](constraints ...Constraint[K, T]) RegistryOption[K, T] {
+	return func(reg *Registry[K, T]) {
+		reg.Constrain(constraints...)
+	}
+}
+
+func LimiterOptionHandler(h LimiterHandler) LimiterOption {
+	return func(l *RateLimiter) {
+		l.Handle(h)
+	}
+}
+
+// LimiterOptionClock sets the clock used by the RateLimiter.
+func LimiterOptionClock(c Clock) LimiterOption {
+	return func(l *RateLimiter) {
+		if c != nil {
+			l.clock = c
+		}
+	}
+}
+
+// BucketOptionClock sets the clock used by the Bucket.
+func BucketOptionClock(c Clock) BucketOption {
+	return func(b *Bucket) {
+		if c != nil {
+			b.clock = c
+		}
+	}
+}
+
+func BackoffOptionHandler[T any](h BackoffHandler[T]) BackoffOption[T] {
+	return func(cd *Backoff[T]) {
+		cd.Handle(h)
+	}
+}
+
+// BackoffOptionClock sets the clock used by the Backoff cooldown.
+func BackoffOptionClock[T any](c Clock) BackoffOption[T] {
+	return func(cd *Backoff[T]) {
+		cd.valued.basic.clock = c
+	}
+}
+
+// DiminishingOptionTable sets step table used for the category instead of the
+// default one.
+func DiminishingOptionTable[K, C comparable](category C, steps ...float64) DiminishingOption[K, C] {
+	return func(d *Diminishing[K, C]) {
+		if len(steps) > 0 {
+			d.tables[category] = slices.Clone(steps)
+		}
+	}
+}
+
+// DiminishingOptionClock sets the clock used by the Diminishing tracker.
+func DiminishingOptionClock[K, C comparable](c Clock) DiminishingOption[K, C] {
+	return func(d *Diminishing[K, C]) {
+		if c != nil {
+			d.clock = c
+		}
+	}
+}
+
+func QuotaOptionHandler(h QuotaHandler) QuotaOption {
+	return func(q *Quota) {
+		q.Handle(h)
+	}
+}
+
+// QuotaOptionClock sets the clock used by the Quota.
+func QuotaOptionClock(c Clock) QuotaOption {
+	return func(q *Quota) {
+		if c != nil {
+			q.clock = c
+		}
+	}
+}
+
+// ValuedOptionQueue enables queueing of the uses attempted within window
+// before the cooldown expires, see Valued.StartQueued. Depth is the maximum
+// count of queued uses, if it is not positive, 1 is used.
+func ValuedOptionQueue[T any](window time.Duration, depth int) ValuedOption[T] {
+	return func(cd *Valued[T]) {
+		cd.queueWindow, cd.queueDepth = window, max(depth, 1)
+	}
+}
+
+// ValuedOptionViolations records attempts to start the cooldown while it is
+// active (via TryStart, TryStartWithTolerance or StartQueued) in provided
+// recorder with provided key.
+func ValuedOptionViolations[K comparable, T any](r *ViolationRecorder[K], key K) ValuedOption[T] {
+	return func(cd *Valued[T]) {
+		cd.violation = func(early time.Duration) {
+			r.Record(key, early)
+		}
+	}
+}
+
+// ValuedOptionOnlineTime makes the cooldown count only the time the process
+// is running: when it is restored, the time passed since the snapshot was
+// taken is not counted, as if the cooldown was paused while the process was
+// down. Snapshot should be taken right before the shutdown then.
+func ValuedOptionOnlineTime[T any]() ValuedOption[T] {
+	return func(cd *Valued[T]) {
+		cd.online = true
+	}
+}
+
+// RegistryOptionViolations records attempts to start cooldowns of the
+// registry while they are active in provided recorder.
+func RegistryOptionViolations[K comparable, T any](r *ViolationRecorder[K]) RegistryOption[K, T] {
+	return func(reg *Registry[K, T]) {
+		reg.violations = r
+	}
+}
+
+func ViolationOptionHandler[K comparable](h ViolationHandler[K]) ViolationOption[K] {
+	return func(r *ViolationRecorder[K]) {
+		r.Handle(h)
+	}
+}
+
+// ViolationOptionBuckets sets upper bounds of the earliness histogram.
+func ViolationOptionBuckets[K comparable](buckets ...time.Duration) ViolationOption[K] {
+	return func(r *ViolationRecorder[K]) {
+		r.buckets = slices.Sorted(slices.Values(buckets))
+	}
+}
+
+// ViolationOptionClock sets the clock used by the ViolationRecorder.
+func ViolationOptionClock[K comparable](c Clock) ViolationOption[K] {
+	return func(r *ViolationRecorder[K]) {
+		if c != nil {
+			r.clock = c
+		}
+	}
+}
+
+// ScopedOptionBypass adds predicate that lets uses pass without cooldowns,
+// e.g. for administrators.
+func ScopedOptionBypass[I comparable, T any](fn func(inv Invocation[I]) bool) ScopedOption[I, T] {
+	return func(s *Scoped[I, T]) {
+		if fn != nil {
+			s.bypass = append(s.bypass, fn)
+		}
+	}
+}
+
+// ScopedOptionRegistry sets options of the registry the scoped cooldowns are
+// stored in.
+func ScopedOptionRegistry[I comparable, T any](opts ...RegistryOption[ScopeKey[I], T]) ScopedOption[I, T] {
+	return func(s *Scoped[I, T]) {
+		s.reg = NewRegistry(opts...)
+	}
+}
+
+// StoredOptionCodec sets the codec used to encode values of the cooldowns.
+// Default is JSONCodec.
+func StoredOptionCodec[T any](c Codec[T]) StoredOption[T] {
+	return func(reg *StoredRegistry[T]) {
+		if c != nil {
+			reg.codec = c
+		}
+	}
+}
+
+// StoredOptionClock sets the clock used by the StoredRegistry.
+func StoredOptionClock[T any](c Clock) StoredOption[T] {
+	return func(reg *StoredRegistry[T]) {
+		if c != nil {
+			reg.clock = c
+		}
+	}
+}
+
+// RegistryOptionReplica makes the registry replicated: pauses and resumes of
+// its cooldowns are stamped with provided clock, so states of the replicas
+// can be merged, see Registry.Merge.
+func RegistryOptionReplica[K comparable, T any](hlc *HLC) RegistryOption[K, T] {
+	return func(reg *Registry[K, T]) {
+		reg.hlc = hlc
+	}
+}
+
+// TokenOptionClock sets the clock used for issue times of the tokens.
+func TokenOptionClock(c Clock) TokenOption {
+	return func(t *Tokens) {
+		if c != nil {
+			t.clock = c
+		}
+	}
+}
@@ -0,0 +1,324 @@
+package cooldown
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/k4ties/cooldown/internal/event"
+)
+
+const (
+	// PhaseWarmup is the name of the warmup (cast) phase created by
+	// CastPhases.
+	PhaseWarmup = "warmup"
+	// PhaseActive is the name of the active phase created by CastPhases.
+	PhaseActive = "active"
+	// PhaseCoolDown is the name of the cooldown phase created by CastPhases.
+	PhaseCoolDown = "cooldown"
+)
+
+// Phase represents single phase of the Phased cooldown.
+type Phase struct {
+	// Name is the name of the phase.
+	Name string
+	// Duration is how long the phase lasts. Phases with non-positive duration
+	// are skipped.
+	Duration time.Duration
+	// Interruptible marks if the phase can be interrupted via
+	// Phased.Interrupt.
+	Interruptible bool
+	// Refund marks that interrupting this phase also cancels all the next
+	// phases. Otherwise, interruption just starts the next phase.
+	Refund bool
+}
+
+// CastPhases returns the common warmup, active and cooldown phases.
+// Interrupting the warmup refunds the cooldown, interrupting the active phase
+// immediately starts the cooldown, and the cooldown itself can't be
+// interrupted.
+func CastPhases(warmup, active, cooldown time.Duration) []Phase {
+	return []Phase{
+		{Name: PhaseWarmup, Duration: warmup, Interruptible: true, Refund: true},
+		{Name: PhaseActive, Duration: active, Interruptible: true},
+		{Name: PhaseCoolDown, Duration: cooldown},
+	}
+}
+
+// Phased is multi-phase cooldown built on Valued. Each phase runs on the
+// underlying Valued, and when it expires the next phase is started.
+type Phased[T any] struct {
+	// All the state is controlled by the lock of the underlying Valued.
+	valued *Valued[T]
+
+	phases  []Phase
+	current int // -1 if inactive
+	val     T
+
+	handler atomic.Pointer[PhasedHandler[T]]
+}
+
+// NewPhased creates new Phased cooldown with provided phases.
+func NewPhased[T any](phases []Phase, opts ...PhasedOption[T]) *Phased[T] {
+	cd := &Phased[T]{phases: append([]Phase(nil), phases...), current: -1}
+	cd.valued = NewValued[T](ValuedOptionHandler[T](phasedHandler[T]{cd: cd}))
+	for _, opt := range opts {
+		if opt == nil {
+			continue
+		}
+		opt(cd)
+	}
+	if cd.handler.Load() == nil {
+		h := PhasedHandler[T](NopPhasedHandler[T]{})
+		cd.handler.Store(&h)
+	}
+	return cd
+}
+
+// Start starts the cooldown from the first phase. If cooldown is already
+// active, it is stopped first.
+func (cooldown *Phased[T]) Start(val T) bool {
+	cooldown.valued.mu.Lock()
+	defer cooldown.valued.mu.Unlock()
+	return cooldown.StartUnsafe(val)
+}
+
+func (cooldown *Phased[T]) StartUnsafe(val T) bool {
+	next := cooldown.nextUnsafe(-1)
+	if next < 0 {
+		return false
+	}
+	if cooldown.ActiveUnsafe() {
+		cooldown.StopUnsafe(val)
+	}
+	ctx := event.C(cooldown)
+	if cooldown.Handler().HandleStart(ctx, val); ctx.Cancelled() {
+		return false
+	}
+	cooldown.val = val
+	cooldown.enterUnsafe(next)
+	return true
+}
+
+// Interrupt interrupts the current phase. If the phase refunds next phases,
+// the cooldown is stopped with ErrStopCauseInterrupted, otherwise the next
+// phase is started. Returns false if cooldown is inactive, current phase
+// is not interruptible, or the event was cancelled.
+func (cooldown *Phased[T]) Interrupt(val T) bool {
+	cooldown.valued.mu.Lock()
+	defer cooldown.valued.mu.Unlock()
+	return cooldown.InterruptUnsafe(val)
+}
+
+func (cooldown *Phased[T]) InterruptUnsafe(val T) bool {
+	phase, ok := cooldown.PhaseUnsafe()
+	if !ok || !phase.Interruptible {
+		return false
+	}
+	ctx := event.C(cooldown)
+	if cooldown.Handler().HandleInterrupt(ctx, phase, val); ctx.Cancelled() {
+		return false
+	}
+	if phase.Refund {
+		cooldown.finishUnsafe(ErrStopCauseInterrupted, val)
+		return true
+	}
+	cooldown.valued.doStopUnsafe(val)
+	cooldown.enterUnsafe(cooldown.nextUnsafe(cooldown.current))
+	return true
+}
+
+// Stop stops the cooldown in any phase.
+func (cooldown *Phased[T]) Stop(val T) {
+	cooldown.valued.mu.Lock()
+	defer cooldown.valued.mu.Unlock()
+	cooldown.StopUnsafe(val)
+}
+
+func (cooldown *Phased[T]) StopUnsafe(val T) {
+	if !cooldown.ActiveUnsafe() {
+		return
+	}
+	cooldown.finishUnsafe(ErrStopCauseCancelled, val)
+}
+
+// finishUnsafe stops the cooldown with provided cause.
+func (cooldown *Phased[T]) finishUnsafe(cause StopCause, val T) {
+	from := cooldown.phases[cooldown.current]
+	cooldown.valued.doStopUnsafe(val)
+	cooldown.current = -1
+	cooldown.Handler().HandlePhase(cooldown, &from, nil, val)
+	cooldown.Handler().HandleStop(cooldown, cause, val)
+}
+
+// enterUnsafe starts phase with provided index. If index is negative, the
+// cooldown is finished.
+func (cooldown *Phased[T]) enterUnsafe(index int) {
+	var from *Phase
+	if cooldown.current >= 0 {
+		phase := cooldown.phases[cooldown.current]
+		from = &phase
+	}
+	if index < 0 {
+		cooldown.current = -1
+		cooldown.Handler().HandlePhase(cooldown, from, nil, cooldown.val)
+		cooldown.Handler().HandleStop(cooldown, ErrStopCauseExpired, cooldown.val)
+		return
+	}
+	to := cooldown.phases[index]
+	cooldown.current = index
+	// Valued is always stopped here and its handler never cancels the start.
+	cooldown.valued.StartUnsafe(to.Duration, cooldown.val)
+	cooldown.Handler().HandlePhase(cooldown, from, &to, cooldown.val)
+}
+
+// nextUnsafe returns index of the next phase with positive duration after
+// provided one, or -1 if there's no such phase.
+func (cooldown *Phased[T]) nextUnsafe(index int) int {
+	for i := index + 1; i < len(cooldown.phases); i++ {
+		if cooldown.phases[i].Duration > 0 {
+			return i
+		}
+	}
+	return -1
+}
+
+// Pause pauses the current phase.
+func (cooldown *Phased[T]) Pause(val T) bool {
+	return cooldown.valued.Pause(val)
+}
+
+func (cooldown *Phased[T]) PauseUnsafe(val T) bool {
+	return cooldown.valued.PauseUnsafe(val)
+}
+
+// Resume resumes the current phase.
+func (cooldown *Phased[T]) Resume(val T) bool {
+	return cooldown.valued.Resume(val)
+}
+
+func (cooldown *Phased[T]) ResumeUnsafe(val T) bool {
+	return cooldown.valued.ResumeUnsafe(val)
+}
+
+// TogglePause ...
+func (cooldown *Phased[T]) TogglePause(val T) bool {
+	return cooldown.valued.TogglePause(val)
+}
+
+func (cooldown *Phased[T]) TogglePauseUnsafe(val T) bool {
+	return cooldown.valued.TogglePauseUnsafe(val)
+}
+
+// Phase returns the current phase. Returns false if cooldown is inactive.
+func (cooldown *Phased[T]) Phase() (Phase, bool) {
+	cooldown.valued.mu.RLock()
+	defer cooldown.valued.mu.RUnlock()
+	return cooldown.PhaseUnsafe()
+}
+
+func (cooldown *Phased[T]) PhaseUnsafe() (Phase, bool) {
+	if cooldown.current < 0 {
+		return Phase{}, false
+	}
+	return cooldown.phases[cooldown.current], true
+}
+
+// Phases returns all phases of the cooldown.
+func (cooldown *Phased[T]) Phases() []Phase {
+	return append([]Phase(nil), cooldown.phases...)
+}
+
+// Active ...
+func (cooldown *Phased[T]) Active() bool {
+	cooldown.valued.mu.RLock()
+	defer cooldown.valued.mu.RUnlock()
+	return cooldown.ActiveUnsafe()
+}
+
+func (cooldown *Phased[T]) ActiveUnsafe() bool {
+	return cooldown.current >= 0
+}
+
+// Remaining returns duration until the current phase ends.
+func (cooldown *Phased[T]) Remaining() time.Duration {
+	return cooldown.valued.Remaining()
+}
+
+func (cooldown *Phased[T]) RemainingUnsafe() time.Duration {
+	return cooldown.valued.RemainingUnsafe()
+}
+
+// RemainingTotal returns duration until the last phase ends.
+func (cooldown *Phased[T]) RemainingTotal() time.Duration {
+	cooldown.valued.mu.RLock()
+	defer cooldown.valued.mu.RUnlock()
+	return cooldown.RemainingTotalUnsafe()
+}
+
+func (cooldown *Phased[T]) RemainingTotalUnsafe() time.Duration {
+	if cooldown.current < 0 {
+		return 0
+	}
+	total := cooldown.valued.RemainingUnsafe()
+	for _, phase := range cooldown.phases[cooldown.current+1:] {
+		if phase.Duration > 0 {
+			total += phase.Duration
+		}
+	}
+	return total
+}
+
+// Paused ...
+func (cooldown *Phased[T]) Paused() bool {
+	return cooldown.valued.Paused()
+}
+
+func (cooldown *Phased[T]) PausedUnsafe() bool {
+	return cooldown.valued.PausedUnsafe()
+}
+
+// Handler ...
+func (cooldown *Phased[T]) Handler() PhasedHandler[T] {
+	return *cooldown.handler.Load()
+}
+
+// Handle ...
+func (cooldown *Phased[T]) Handle(handler PhasedHandler[T]) {
+	if handler == nil {
+		handler = NopPhasedHandler[T]{}
+	}
+	cooldown.handler.Store(&handler)
+}
+
+// Valued returns the underlying Valued cooldown. Its handler is used by the
+// Phased cooldown, so it must not be changed.
+func (cooldown *Phased[T]) Valued() *Valued[T] {
+	return cooldown.valued
+}
+
+// phasedHandler drives phase transitions of Phased via the underlying Valued.
+type phasedHandler[T any] struct {
+	NopValuedHandler[T]
+	cd *Phased[T]
+}
+
+func (h phasedHandler[T]) HandleStop(_ *Valued[T], cause StopCause, _ T) {
+	if cause != ErrStopCauseExpired || h.cd.current < 0 {
+		return
+	}
+	h.cd.enterUnsafe(h.cd.nextUnsafe(h.cd.current))
+}
+
+func (h phasedHandler[T]) HandlePause(parent *ValuedContext[T], val T) {
+	ctx := event.C(h.cd)
+	if h.cd.Handler().HandlePause(ctx, h.cd.phases[h.cd.current], val); ctx.Cancelled() {
+		parent.Cancel()
+	}
+}
+
+func (h phasedHandler[T]) HandleResume(parent *ValuedContext[T], val T) {
+	ctx := event.C(h.cd)
+	if h.cd.Handler().HandleResume(ctx, h.cd.phases[h.cd.current], val); ctx.Cancelled() {
+		parent.Cancel()
+	}
+}
@@ -0,0 +1,98 @@
+package cooldown_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+	"github.com/k4ties/cooldown"
+)
+
+type phaseRecorder struct {
+	cooldown.NopPhasedHandler[int]
+
+	mu     sync.Mutex
+	phases []string
+	causes []cooldown.StopCause
+}
+
+func (h *phaseRecorder) HandlePhase(_ *cooldown.Phased[int], _, to *cooldown.Phase, _ int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if to != nil {
+		h.phases = append(h.phases, to.Name)
+	}
+}
+
+func (h *phaseRecorder) HandleStop(_ *cooldown.Phased[int], cause cooldown.StopCause, _ int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.causes = append(h.causes, cause)
+}
+
+func (h *phaseRecorder) result() ([]string, []cooldown.StopCause) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]string(nil), h.phases...), append([]cooldown.StopCause(nil), h.causes...)
+}
+
+func TestPhased(t *testing.T) {
+	t.Run("phase transitions", func(t *testing.T) {
+		h := new(phaseRecorder)
+		p := cooldown.NewPhased[int](cooldown.CastPhases(time.Millisecond*10, 0, time.Millisecond*10), cooldown.PhasedOptionHandler[int](h))
+
+		assert.Equal(t, p.Start(1), true)
+		phase, ok := p.Phase()
+		assert.Equal(t, ok, true)
+		assert.Equal(t, phase.Name, cooldown.PhaseWarmup)
+		// Phases are started through the underlying Valued.
+		s := p.Valued().Snapshot()
+		assert.Equal(t, s.Val, 1)
+		assert.Equal(t, s.Duration, time.Millisecond*10)
+
+		<-time.After(time.Millisecond * 50)
+		assert.Equal(t, p.Active(), false)
+		phases, causes := h.result()
+		// Active phase has zero duration, so it is skipped
+		assert.Equal(t, phases, []string{cooldown.PhaseWarmup, cooldown.PhaseCoolDown})
+		assert.Equal(t, causes, []cooldown.StopCause{cooldown.ErrStopCauseExpired})
+	})
+	t.Run("interrupt refunds cooldown", func(t *testing.T) {
+		h := new(phaseRecorder)
+		p := cooldown.NewPhased[int](cooldown.CastPhases(time.Second, time.Second, time.Second), cooldown.PhasedOptionHandler[int](h))
+
+		assert.Equal(t, p.Start(1), true)
+		assert.Equal(t, p.Interrupt(1), true)
+		assert.Equal(t, p.Active(), false)
+		_, causes := h.result()
+		assert.Equal(t, causes, []cooldown.StopCause{cooldown.ErrStopCauseInterrupted})
+	})
+	t.Run("interrupt starts next phase", func(t *testing.T) {
+		p := cooldown.NewPhased[int](cooldown.CastPhases(0, time.Second, time.Second))
+
+		assert.Equal(t, p.Start(1), true)
+		assert.Equal(t, p.Interrupt(1), true)
+		phase, _ := p.Phase()
+		assert.Equal(t, phase.Name, cooldown.PhaseCoolDown)
+		// Cooldown phase is not interruptible
+		assert.Equal(t, p.Interrupt(1), false)
+	})
+	t.Run("pause applies to current phase", func(t *testing.T) {
+		p := cooldown.NewPhased[int](cooldown.CastPhases(time.Millisecond*10, 0, time.Second))
+
+		assert.Equal(t, p.Start(1), true)
+		assert.Equal(t, p.Pause(1), true)
+		<-time.After(time.Millisecond * 30)
+		phase, _ := p.Phase()
+		assert.Equal(t, phase.Name, cooldown.PhaseWarmup)
+		assert.Equal(t, p.Resume(1), true)
+		// Time spent in pause is not counted
+		assert.Equal(t, p.Remaining() > time.Millisecond*5, true)
+		phase, _ = p.Phase()
+		assert.Equal(t, phase.Name, cooldown.PhaseWarmup)
+		<-time.After(time.Millisecond * 30)
+		phase, _ = p.Phase()
+		assert.Equal(t, phase.Name, cooldown.PhaseCoolDown)
+	})
+}
@@ -0,0 +1,116 @@
+package cooldown
+
+import (
+	"slices"
+	"time"
+)
+
+// QueuedUse is the use of the Valued cooldown queued by StartQueued. It is
+// executed (the cooldown is started with its duration and value) right when
+// the cooldown expires.
+type QueuedUse[T any] struct {
+	cooldown *Valued[T]
+	dur      time.Duration
+	val      T
+
+	done    chan struct{}
+	started bool
+}
+
+// Value returns the value the use was queued with.
+func (use *QueuedUse[T]) Value() T {
+	return use.val
+}
+
+// Done returns channel that is closed when the use is executed or cancelled.
+func (use *QueuedUse[T]) Done() <-chan struct{} {
+	return use.done
+}
+
+// Started returns true if the use was executed and the cooldown was started.
+// It is false while the use is still queued, or if it was cancelled.
+func (use *QueuedUse[T]) Started() bool {
+	use.cooldown.mu.RLock()
+	defer use.cooldown.mu.RUnlock()
+	return use.started
+}
+
+// Cancel removes the use from the queue. Returns false if it was already
+// executed or cancelled.
+func (use *QueuedUse[T]) Cancel() bool {
+	use.cooldown.mu.Lock()
+	defer use.cooldown.mu.Unlock()
+	return use.cooldown.cancelQueuedUnsafe(use)
+}
+
+// StartQueued starts the cooldown if it is not active. If it is active, but
+// expires within the queue window (see ValuedOptionQueue), the use is queued
+// and executed right when the cooldown expires. Returned QueuedUse is nil if
+// the cooldown was started immediately.
+//
+// Returns *ErrOnCooldown if the cooldown doesn't expire within the window,
+// ErrQueueFull if there are too many queued uses, and ErrStartCancelled if the
+// immediate start was cancelled by handler.
+//
+// Queued uses are cancelled if the cooldown is stopped instead of expiring.
+func (cooldown *Valued[T]) StartQueued(dur time.Duration, val T) (*QueuedUse[T], error) {
+	defer cooldown.lockStart()()
+	return cooldown.StartQueuedUnsafe(dur, val)
+}
+
+func (cooldown *Valued[T]) StartQueuedUnsafe(dur time.Duration, val T) (*QueuedUse[T], error) {
+	if !cooldown.ActiveUnsafe() {
+		// The cooldown may have expired while its timer waits for the lock,
+		// so the uses queued earlier are executed first.
+		cooldown.runQueueUnsafe()
+		if !cooldown.ActiveUnsafe() {
+			return nil, cooldown.startUnsafe(dur, val)
+		}
+	}
+	remaining := cooldown.RemainingUnsafe()
+	if remaining > cooldown.queueWindow {
+		cooldown.violateUnsafe()
+		return nil, &ErrOnCooldown{Remaining: remaining}
+	}
+	if len(cooldown.queue) >= cooldown.queueDepth {
+		return nil, ErrQueueFull
+	}
+	use := &QueuedUse[T]{cooldown: cooldown, dur: dur, val: val, done: make(chan struct{})}
+	cooldown.queue = append(cooldown.queue, use)
+	return use, nil
+}
+
+// Queued returns count of queued uses.
+func (cooldown *Valued[T]) Queued() int {
+	cooldown.mu.RLock()
+	defer cooldown.mu.RUnlock()
+	return len(cooldown.queue)
+}
+
+// runQueueUnsafe executes queued uses until one of them starts the cooldown.
+func (cooldown *Valued[T]) runQueueUnsafe() {
+	for len(cooldown.queue) > 0 && !cooldown.ActiveUnsafe() {
+		use := cooldown.queue[0]
+		cooldown.queue = cooldown.queue[1:]
+		use.started = cooldown.StartUnsafe(use.dur, use.val)
+		close(use.done)
+	}
+}
+
+// flushQueueUnsafe cancels all the queued uses.
+func (cooldown *Valued[T]) flushQueueUnsafe() {
+	for _, use := range cooldown.queue {
+		close(use.done)
+	}
+	cooldown.queue = nil
+}
+
+func (cooldown *Valued[T]) cancelQueuedUnsafe(use *QueuedUse[T]) bool {
+	i := slices.Index(cooldown.queue, use)
+	if i < 0 {
+		return false
+	}
+	cooldown.queue = slices.Delete(cooldown.queue, i, i+1)
+	close(use.done)
+	return true
+}
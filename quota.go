@@ -0,0 +1,204 @@
+package cooldown
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/k4ties/cooldown/internal/event"
+)
+
+// Schedule defines the wall-clock times when Quota resets.
+type Schedule interface {
+	// Next returns the first reset time after t.
+	Next(t time.Time) time.Time
+}
+
+// Daily returns Schedule that resets every day at provided time in provided
+// location. If loc is nil, time.Local is used.
+//
+// Days are counted in the calendar of the location, so DST transitions don't
+// shift the reset time. If the time doesn't exist on some day (it is skipped
+// by DST transition), reset happens at the corresponding time after the
+// transition, as time.Date normalizes it.
+func Daily(hour, min int, loc *time.Location) Schedule {
+	return calendarSchedule{hour: hour, min: min, loc: loc, weekday: -1}
+}
+
+// Weekly returns Schedule that resets every week on provided weekday at
+// provided time in provided location. If loc is nil, time.Local is used.
+func Weekly(day time.Weekday, hour, min int, loc *time.Location) Schedule {
+	return calendarSchedule{hour: hour, min: min, loc: loc, weekday: day}
+}
+
+// calendarSchedule is the daily or weekly Schedule.
+type calendarSchedule struct {
+	hour, min int
+	loc       *time.Location
+	// weekday is the day of the weekly schedule, or -1 if it is daily.
+	weekday time.Weekday
+}
+
+// Next ...
+func (s calendarSchedule) Next(t time.Time) time.Time {
+	loc := s.loc
+	if loc == nil {
+		loc = time.Local
+	}
+	local := t.In(loc)
+	year, month, day := local.Date()
+	if s.weekday >= 0 {
+		day += (int(s.weekday) - int(local.Weekday()) + 7) % 7
+	}
+	step := 1
+	if s.weekday >= 0 {
+		step = 7
+	}
+	// Days are added via time.Date rather than Add, so the days with DST
+	// transitions (23 or 25 hours long) are handled correctly.
+	next := time.Date(year, month, day, s.hour, s.min, 0, 0, loc)
+	for !next.After(t) {
+		day += step
+		next = time.Date(year, month, day, s.hour, s.min, 0, 0, loc)
+	}
+	return next
+}
+
+// Quota allows limit uses between resets happening on Schedule, e.g. daily
+// rewards or weekly boss attempts. Quota with the limit of one works as a
+// cooldown that expires on the next reset.
+//
+// Resets are applied lazily, so HandleReset is called on the next method call
+// after the reset time.
+type Quota struct {
+	mu sync.Mutex
+
+	limit    int
+	used     int
+	schedule Schedule
+	// next is the time of the next reset. It is zero if quota wasn't used yet.
+	next  time.Time
+	clock Clock
+
+	handler atomic.Pointer[QuotaHandler]
+}
+
+// NewQuota creates new Quota with provided limit and reset schedule. If limit
+// is not positive, quota is always exhausted.
+func NewQuota(limit int, schedule Schedule, opts ...QuotaOption) *Quota {
+	q := &Quota{limit: limit, schedule: schedule, clock: SystemClock{}}
+	for _, opt := range opts {
+		if opt == nil {
+			continue
+		}
+		opt(q)
+	}
+	if q.handler.Load() == nil {
+		h := QuotaHandler(NopQuotaHandler{})
+		q.handler.Store(&h)
+	}
+	return q
+}
+
+// Use takes single use of the quota. Returns false if quota is exhausted or
+// the use was cancelled by handler.
+func (q *Quota) Use() bool {
+	return q.UseN(1)
+}
+
+// UseN takes n uses at once. Either all of them are taken or none.
+func (q *Quota) UseN(n int) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.UseNUnsafe(n)
+}
+
+func (q *Quota) UseNUnsafe(n int) bool {
+	now := q.clock.Now()
+	q.resetUnsafe(now)
+	if n <= 0 || q.used+n > q.limit {
+		return false
+	}
+	ctx := event.C(q)
+	if q.Handler().HandleUse(ctx, n); ctx.Cancelled() {
+		return false
+	}
+	if q.next.IsZero() {
+		q.next = q.schedule.Next(now)
+	}
+	q.used += n
+	return true
+}
+
+// resetUnsafe resets the quota if reset time has passed.
+func (q *Quota) resetUnsafe(now time.Time) {
+	if q.next.IsZero() || q.next.After(now) {
+		return
+	}
+	at := q.next
+	q.used, q.next = 0, time.Time{}
+	q.Handler().HandleReset(q, at)
+}
+
+// Left returns count of uses left until the next reset.
+func (q *Quota) Left() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.resetUnsafe(q.clock.Now())
+	return max(q.limit-q.used, 0)
+}
+
+// NextReset returns the time of the next reset.
+func (q *Quota) NextReset() time.Time {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	now := q.clock.Now()
+	q.resetUnsafe(now)
+	if q.next.IsZero() {
+		return q.schedule.Next(now)
+	}
+	return q.next
+}
+
+// Remaining returns duration until the quota can be used again. It is zero if
+// there are uses left.
+func (q *Quota) Remaining() time.Duration {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	now := q.clock.Now()
+	q.resetUnsafe(now)
+	if q.used < q.limit {
+		return 0
+	}
+	next := q.next
+	if next.IsZero() {
+		// Quota with non-positive limit is exhausted without any use.
+		next = q.schedule.Next(now)
+	}
+	return max(next.Sub(now), 0)
+}
+
+// Active returns true if quota is exhausted.
+func (q *Quota) Active() bool {
+	return q.Remaining() > 0
+}
+
+// Reset resets the quota immediately.
+func (q *Quota) Reset() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.used, q.next = 0, time.Time{}
+}
+
+// Handler ...
+func (q *Quota) Handler() QuotaHandler {
+	return *q.handler.Load()
+}
+
+// Handle ...
+func (q *Quota) Handle(handler QuotaHandler) {
+	if handler == nil {
+		handler = NopQuotaHandler{}
+	}
+	q.handler.Store(&handler)
+}
@@ -0,0 +1,80 @@
+package cooldown_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+	"github.com/k4ties/cooldown"
+)
+
+func berlin(t *testing.T) *time.Location {
+	loc, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("time zone database is not available: %v", err)
+	}
+	return loc
+}
+
+func TestSchedule(t *testing.T) {
+	loc := berlin(t)
+	t.Run("daily across DST", func(t *testing.T) {
+		daily := cooldown.Daily(0, 0, loc)
+		first := daily.Next(time.Date(2025, time.March, 29, 12, 0, 0, 0, loc))
+		assert.Equal(t, first.Equal(time.Date(2025, time.March, 30, 0, 0, 0, 0, loc)), true)
+
+		// The day of the transition is only 23 hours long
+		second := daily.Next(first)
+		assert.Equal(t, second.Equal(time.Date(2025, time.March, 31, 0, 0, 0, 0, loc)), true)
+		assert.Equal(t, second.Sub(first), time.Hour*23)
+	})
+	t.Run("daily at skipped time", func(t *testing.T) {
+		next := cooldown.Daily(2, 30, loc).Next(time.Date(2025, time.March, 30, 0, 0, 0, 0, loc))
+		assert.Equal(t, next.In(loc).Hour(), 3)
+		assert.Equal(t, next.In(loc).Day(), 30)
+	})
+	t.Run("weekly", func(t *testing.T) {
+		weekly := cooldown.Weekly(time.Monday, 0, 0, loc)
+		next := weekly.Next(time.Date(2025, time.January, 1, 12, 0, 0, 0, loc))
+		assert.Equal(t, next.Equal(time.Date(2025, time.January, 6, 0, 0, 0, 0, loc)), true)
+		assert.Equal(t, weekly.Next(next).Equal(time.Date(2025, time.January, 13, 0, 0, 0, 0, loc)), true)
+	})
+}
+
+type resetHandler struct {
+	cooldown.NopQuotaHandler
+	resets []time.Time
+}
+
+func (h *resetHandler) HandleReset(_ *cooldown.Quota, at time.Time) {
+	h.resets = append(h.resets, at)
+}
+
+func TestQuota(t *testing.T) {
+	clock := newFakeClock()
+	h := new(resetHandler)
+	q := cooldown.NewQuota(2, cooldown.Daily(0, 0, time.UTC), cooldown.QuotaOptionClock(clock), cooldown.QuotaOptionHandler(h))
+
+	clock.Advance(time.Hour * 20)
+	assert.Equal(t, q.Use(), true)
+	assert.Equal(t, q.Use(), true)
+	assert.Equal(t, q.Use(), false)
+	assert.Equal(t, q.Left(), 0)
+	assert.Equal(t, q.Remaining(), time.Hour*4)
+
+	clock.Advance(time.Hour * 4)
+	assert.Equal(t, q.Left(), 2)
+	assert.Equal(t, len(h.resets), 1)
+	assert.Equal(t, q.NextReset().Sub(clock.Now()), time.Hour*24)
+}
+
+func TestQuotaZeroLimit(t *testing.T) {
+	clock := newFakeClock()
+	q := cooldown.NewQuota(0, cooldown.Daily(0, 0, time.UTC), cooldown.QuotaOptionClock(clock))
+
+	clock.Advance(time.Hour * 20)
+	assert.Equal(t, q.Use(), false)
+	assert.Equal(t, q.Left(), 0)
+	assert.Equal(t, q.Remaining(), time.Hour*4)
+	assert.Equal(t, q.Active(), true)
+}
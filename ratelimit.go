@@ -0,0 +1,414 @@
+package cooldown
+
+import (
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/k4ties/cooldown/internal/event"
+)
+
+// Window is the algorithm used by RateLimiter to count uses.
+type Window uint8
+
+const (
+	// FixedWindow counts uses in fixed windows. Window starts on the first
+	// use after the previous one has ended.
+	FixedWindow Window = iota
+	// SlidingLog remembers time of every use and allows at most limit uses in
+	// any window. It is precise, but stores up to limit timestamps.
+	SlidingLog
+	// SlidingWindow approximates sliding log by weighting count of the
+	// previous fixed window by its overlap with the sliding one.
+	SlidingWindow
+)
+
+// RateLimiter allows at most limit uses per window. Like Basic, it can be
+// paused: while it is paused the time doesn't pass for it, so windows don't
+// end and logged uses don't expire.
+type RateLimiter struct {
+	mu sync.RWMutex
+
+	kind   Window
+	limit  int
+	window time.Duration
+
+	// start is the start of the current window (FixedWindow and
+	// SlidingWindow). All times are on the limiter clock, see nowUnsafe.
+	start time.Time
+	// count is the count of uses in the current window, prev is the count of
+	// the previous one.
+	count, prev int
+	// log contains times of the uses (SlidingLog).
+	log []time.Time
+
+	// pausedAt is the time when limiter was paused.
+	pausedAt time.Time
+	// shift is the total duration limiter was paused for.
+	shift time.Duration
+	clock Clock
+
+	handler atomic.Pointer[LimiterHandler]
+}
+
+// NewRateLimiter creates new RateLimiter allowing limit uses per window.
+// Non-positive window is clamped to the shortest one (a nanosecond), so uses
+// are forgotten almost immediately.
+func NewRateLimiter(kind Window, limit int, window time.Duration, opts ...LimiterOption) *RateLimiter {
+	l := &RateLimiter{kind: kind, limit: limit, window: max(window, 1), clock: SystemClock{}}
+	for _, opt := range opts {
+		if opt == nil {
+			continue
+		}
+		opt(l)
+	}
+	if l.handler.Load() == nil {
+		h := LimiterHandler(NopLimiterHandler{})
+		l.handler.Store(&h)
+	}
+	return l
+}
+
+// Use registers single use. Returns false if the limit is reached or the use
+// was cancelled by handler.
+func (l *RateLimiter) Use() bool {
+	return l.UseN(1)
+}
+
+func (l *RateLimiter) UseUnsafe() bool {
+	return l.UseNUnsafe(1)
+}
+
+// UseN registers n uses at once. Either all of them are registered or none.
+func (l *RateLimiter) UseN(n int) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.UseNUnsafe(n)
+}
+
+func (l *RateLimiter) UseNUnsafe(n int) bool {
+	if n <= 0 {
+		return true
+	}
+	now := l.nowUnsafe()
+	l.advanceUnsafe(now)
+	if retry := l.retryAfterUnsafe(now, n); retry > 0 {
+		l.Handler().HandleLimitReached(l, n, retry)
+		return false
+	}
+	ctx := event.C(l)
+	if l.Handler().HandleUse(ctx, n); ctx.Cancelled() {
+		return false
+	}
+	switch l.kind {
+	case SlidingLog:
+		for range n {
+			l.log = append(l.log, now)
+		}
+	default:
+		if l.start.IsZero() {
+			l.start = now
+		}
+		l.count += n
+	}
+	return true
+}
+
+// Remaining returns duration until the next use will be allowed. It is zero
+// if the use is allowed right now.
+func (l *RateLimiter) Remaining() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.RemainingUnsafe()
+}
+
+func (l *RateLimiter) RemainingUnsafe() time.Duration {
+	now := l.nowUnsafe()
+	l.advanceUnsafe(now)
+	return l.retryAfterUnsafe(now, 1)
+}
+
+// Available returns count of uses allowed right now.
+func (l *RateLimiter) Available() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.AvailableUnsafe()
+}
+
+func (l *RateLimiter) AvailableUnsafe() int {
+	now := l.nowUnsafe()
+	l.advanceUnsafe(now)
+
+	used := l.count
+	switch l.kind {
+	case SlidingLog:
+		used = len(l.log)
+	case SlidingWindow:
+		used += int(math.Ceil(float64(l.prev) * l.weightUnsafe(now)))
+	}
+	return max(l.limit-used, 0)
+}
+
+// advanceUnsafe forgets uses that are out of the window.
+func (l *RateLimiter) advanceUnsafe(now time.Time) {
+	switch l.kind {
+	case SlidingLog:
+		i := 0
+		for i < len(l.log) && !l.log[i].Add(l.window).After(now) {
+			i++
+		}
+		l.log = l.log[i:]
+	case FixedWindow:
+		if !l.start.IsZero() && !l.start.Add(l.window).After(now) {
+			l.start, l.count = time.Time{}, 0
+		}
+	case SlidingWindow:
+		if l.start.IsZero() {
+			return
+		}
+		passed := int(now.Sub(l.start) / l.window)
+		if passed <= 0 {
+			return
+		}
+		l.prev = 0
+		if passed == 1 {
+			l.prev = l.count
+		}
+		l.count = 0
+		// Windows stay aligned to the first one.
+		l.start = l.start.Add(time.Duration(passed) * l.window)
+	}
+}
+
+// weightUnsafe returns weight of the previous window in the sliding one.
+func (l *RateLimiter) weightUnsafe(now time.Time) float64 {
+	if l.start.IsZero() || l.window <= 0 {
+		return 0
+	}
+	return 1 - float64(now.Sub(l.start))/float64(l.window)
+}
+
+// retryAfterUnsafe returns duration until n uses will be allowed.
+func (l *RateLimiter) retryAfterUnsafe(now time.Time, n int) time.Duration {
+	if n > l.limit {
+		// Will never be allowed, at least whole window must pass.
+		return l.window
+	}
+	switch l.kind {
+	case SlidingLog:
+		over := len(l.log) + n - l.limit
+		if over <= 0 {
+			return 0
+		}
+		return l.log[over-1].Add(l.window).Sub(now)
+	case FixedWindow:
+		if l.count+n <= l.limit {
+			return 0
+		}
+		return l.start.Add(l.window).Sub(now)
+	default:
+		elapsed := now.Sub(l.start)
+		count, prev := l.count, l.prev
+		var wait time.Duration
+		if count+n > l.limit {
+			// Current window is full, so we have to wait for the next one
+			// where it becomes the previous one.
+			wait = l.window - elapsed
+			elapsed, count, prev = 0, 0, count
+		}
+		if prev == 0 {
+			return wait
+		}
+		// Solve prev*(1-elapsed/window)+count+n <= limit for elapsed.
+		need := l.window - time.Duration(float64(l.window)*float64(l.limit-count-n)/float64(prev))
+		if need > elapsed {
+			wait += need - elapsed
+		}
+		return wait
+	}
+}
+
+// Pause pauses the limiter. Returns true if it was paused.
+func (l *RateLimiter) Pause() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.PauseUnsafe()
+}
+
+func (l *RateLimiter) PauseUnsafe() bool {
+	if l.PausedUnsafe() {
+		return false
+	}
+	l.pausedAt = l.clock.Now()
+	return true
+}
+
+// Resume resumes the limiter. Returns true if it was resumed.
+func (l *RateLimiter) Resume() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.ResumeUnsafe()
+}
+
+func (l *RateLimiter) ResumeUnsafe() bool {
+	if !l.PausedUnsafe() {
+		return false
+	}
+	l.shift += l.clock.Now().Sub(l.pausedAt)
+	l.pausedAt = time.Time{}
+	return true
+}
+
+// TogglePause toggles the pause state of the limiter.
+func (l *RateLimiter) TogglePause() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.TogglePauseUnsafe()
+}
+
+func (l *RateLimiter) TogglePauseUnsafe() bool {
+	if l.PausedUnsafe() {
+		return l.ResumeUnsafe()
+	}
+	return l.PauseUnsafe()
+}
+
+// Paused returns true if limiter is paused.
+func (l *RateLimiter) Paused() bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.PausedUnsafe()
+}
+
+func (l *RateLimiter) PausedUnsafe() bool {
+	return !l.pausedAt.IsZero()
+}
+
+// Reset forgets all the uses.
+func (l *RateLimiter) Reset() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.ResetUnsafe()
+}
+
+func (l *RateLimiter) ResetUnsafe() {
+	l.start, l.count, l.prev, l.log = time.Time{}, 0, 0, nil
+}
+
+// nowUnsafe returns the current time on the limiter clock, which doesn't go
+// while the limiter is paused.
+func (l *RateLimiter) nowUnsafe() time.Time {
+	now := l.clock.Now()
+	if l.PausedUnsafe() {
+		now = l.pausedAt
+	}
+	return now.Add(-l.shift)
+}
+
+// Limit returns the count of uses allowed per window.
+func (l *RateLimiter) Limit() int {
+	return l.limit
+}
+
+// Window returns duration of the window.
+func (l *RateLimiter) Window() time.Duration {
+	return l.window
+}
+
+// Handler ...
+func (l *RateLimiter) Handler() LimiterHandler {
+	return *l.handler.Load()
+}
+
+// Handle ...
+func (l *RateLimiter) Handle(handler LimiterHandler) {
+	if handler == nil {
+		handler = NopLimiterHandler{}
+	}
+	l.handler.Store(&handler)
+}
+
+func (l *RateLimiter) L() *sync.RWMutex {
+	return &l.mu
+}
+
+// KeyedLimiter is keyed collection of rate limiters. Limiters are created
+// lazily with provided function on first use of the key.
+type KeyedLimiter[K comparable] struct {
+	mu sync.RWMutex
+
+	limiters map[K]*RateLimiter
+	create   func(key K) *RateLimiter
+}
+
+// NewKeyedLimiter creates new KeyedLimiter. Create is called for every new
+// key, so it can set handler knowing the key.
+func NewKeyedLimiter[K comparable](create func(key K) *RateLimiter) *KeyedLimiter[K] {
+	return &KeyedLimiter[K]{limiters: make(map[K]*RateLimiter), create: create}
+}
+
+// Get returns limiter with provided key, creating it if it doesn't exist.
+func (k *KeyedLimiter[K]) Get(key K) *RateLimiter {
+	k.mu.RLock()
+	l, ok := k.limiters[key]
+	k.mu.RUnlock()
+	if ok {
+		return l
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if l, ok = k.limiters[key]; !ok {
+		l = k.create(key)
+		k.limiters[key] = l
+	}
+	return l
+}
+
+// Use registers single use of the key.
+func (k *KeyedLimiter[K]) Use(key K) bool {
+	return k.Get(key).Use()
+}
+
+// UseN registers n uses of the key.
+func (k *KeyedLimiter[K]) UseN(key K, n int) bool {
+	return k.Get(key).UseN(n)
+}
+
+// Remaining returns duration until the next use of the key will be allowed.
+func (k *KeyedLimiter[K]) Remaining(key K) time.Duration {
+	k.mu.RLock()
+	l, ok := k.limiters[key]
+	k.mu.RUnlock()
+	if !ok {
+		return 0
+	}
+	return l.Remaining()
+}
+
+// Delete removes limiter with provided key.
+func (k *KeyedLimiter[K]) Delete(key K) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	delete(k.limiters, key)
+}
+
+// Prune removes limiters that have all the uses available and aren't paused,
+// so they are indistinguishable from the new ones.
+func (k *KeyedLimiter[K]) Prune() {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	for key, l := range k.limiters {
+		if !l.Paused() && l.Available() == l.limit {
+			delete(k.limiters, key)
+		}
+	}
+}
+
+// Len returns count of limiters.
+func (k *KeyedLimiter[K]) Len() int {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return len(k.limiters)
+}
@@ -0,0 +1,77 @@
+package cooldown_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+	"github.com/k4ties/cooldown"
+)
+
+type limitHandler struct {
+	cooldown.NopLimiterHandler
+	reached    int
+	retryAfter time.Duration
+}
+
+func (h *limitHandler) HandleLimitReached(_ *cooldown.RateLimiter, _ int, retryAfter time.Duration) {
+	h.reached++
+	h.retryAfter = retryAfter
+}
+
+func TestRateLimiter(t *testing.T) {
+	for _, kind := range []cooldown.Window{cooldown.FixedWindow, cooldown.SlidingLog, cooldown.SlidingWindow} {
+		h := new(limitHandler)
+		l := cooldown.NewRateLimiter(kind, 3, time.Millisecond*30, cooldown.LimiterOptionHandler(h))
+
+		assert.Equal(t, l.Available(), 3)
+		assert.Equal(t, l.UseN(2), true)
+		assert.Equal(t, l.Use(), true)
+		assert.Equal(t, l.Available(), 0)
+		assert.Equal(t, l.Use(), false)
+		assert.Equal(t, h.reached, 1)
+		assert.Equal(t, h.retryAfter > 0, true)
+		assert.Equal(t, l.Remaining() > 0, true)
+		// More uses than the limit are never allowed
+		assert.Equal(t, l.UseN(4), false)
+
+		<-time.After(l.Remaining() + time.Millisecond)
+		assert.Equal(t, l.Use(), true)
+
+		// Uses are forgotten almost immediately if window is empty.
+		clock := newFakeClock()
+		l = cooldown.NewRateLimiter(kind, 1, 0, cooldown.LimiterOptionClock(clock))
+		assert.Equal(t, l.Use(), true)
+		clock.Advance(time.Nanosecond * 2)
+		assert.Equal(t, l.Use(), true)
+		assert.Equal(t, l.UseN(2), false)
+	}
+}
+
+func TestRateLimiterPause(t *testing.T) {
+	l := cooldown.NewRateLimiter(cooldown.SlidingLog, 1, time.Millisecond*20)
+	assert.Equal(t, l.Use(), true)
+	assert.Equal(t, l.Pause(), true)
+	assert.Equal(t, l.Pause(), false)
+
+	// The time doesn't pass while limiter is paused
+	<-time.After(time.Millisecond * 30)
+	assert.Equal(t, l.Use(), false)
+	assert.Equal(t, l.Resume(), true)
+	assert.Equal(t, l.Use(), false)
+
+	<-time.After(l.Remaining() + time.Millisecond)
+	assert.Equal(t, l.Use(), true)
+}
+
+func TestKeyedLimiter(t *testing.T) {
+	k := cooldown.NewKeyedLimiter(func(string) *cooldown.RateLimiter {
+		return cooldown.NewRateLimiter(cooldown.FixedWindow, 1, time.Second)
+	})
+	assert.Equal(t, k.Use("a"), true)
+	assert.Equal(t, k.Use("a"), false)
+	assert.Equal(t, k.Use("b"), true)
+	assert.Equal(t, k.Len(), 2)
+	assert.Equal(t, k.Remaining("a") > 0, true)
+	assert.Equal(t, k.Remaining("c"), time.Duration(0))
+}
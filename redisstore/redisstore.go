@@ -0,0 +1,346 @@
+// Package redisstore implements cooldown.Store over Redis, speaking RESP
+// directly without third-party clients.
+//
+// Records are stored as strings in the binary form of cooldown.Record.
+// Records of running cooldowns are set with PX, so they are removed by Redis
+// right when the cooldown expires. Compare-and-set is implemented with
+// WATCH/MULTI/EXEC, so no Lua scripting is required.
+package redisstore
+
+import (
+	"context"
+	"errors"
+	"net"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/k4ties/cooldown"
+	"github.com/k4ties/cooldown/internal/resp"
+)
+
+// Option is the option of the Store.
+type Option func(s *Store)
+
+// OptionPassword authenticates connections with provided password.
+func OptionPassword(password string) Option {
+	return func(s *Store) {
+		s.password = password
+	}
+}
+
+// OptionDB selects the database of the connections.
+func OptionDB(db int) Option {
+	return func(s *Store) {
+		s.db = db
+	}
+}
+
+// OptionPoolSize sets maximum count of idle connections. Default is 8.
+func OptionPoolSize(n int) Option {
+	return func(s *Store) {
+		if n > 0 {
+			s.pool = make(chan *conn, n)
+		}
+	}
+}
+
+// OptionRetention sets how long records are kept after the cooldown expires
+// or is stopped. By default, records of running cooldowns are removed right
+// when they expire, and records of stopped and paused cooldowns are kept
+// until they are deleted.
+func OptionRetention(d time.Duration) Option {
+	return func(s *Store) {
+		s.retention = max(d, 0)
+	}
+}
+
+// OptionClock sets the clock used to compute expirations of the records.
+func OptionClock(c cooldown.Clock) Option {
+	return func(s *Store) {
+		if c != nil {
+			s.clock = c
+		}
+	}
+}
+
+// Store is the cooldown.Store over Redis.
+type Store struct {
+	addr     string
+	password string
+	db       int
+	dialer   net.Dialer
+
+	retention time.Duration
+	clock     cooldown.Clock
+
+	pool chan *conn
+}
+
+// New creates new Store using Redis server with provided address. Connections
+// are opened lazily.
+func New(addr string, opts ...Option) *Store {
+	s := &Store{addr: addr, clock: cooldown.SystemClock{}, pool: make(chan *conn, 8)}
+	for _, opt := range opts {
+		if opt == nil {
+			continue
+		}
+		opt(s)
+	}
+	return s
+}
+
+// Get ...
+func (s *Store) Get(ctx context.Context, key string) (rec cooldown.Record, ok bool, err error) {
+	err = s.with(ctx, func(c *conn) error {
+		v, err := c.do("GET", key)
+		if err != nil || v.Nil {
+			return err
+		}
+		ok = true
+		return rec.UnmarshalBinary(v.Str)
+	})
+	return rec, ok, err
+}
+
+// CompareAndSet ...
+func (s *Store) CompareAndSet(ctx context.Context, key string, version uint64, rec cooldown.Record) (next uint64, err error) {
+	rec.Version = version + 1
+	data, err := rec.MarshalBinary()
+	if err != nil {
+		return 0, err
+	}
+	set := []any{"SET", key, data}
+	if ttl, ok := s.ttl(rec); ok {
+		// PX must be positive, so the TTL is rounded up to whole milliseconds.
+		set = append(set, "PX", int64((ttl+time.Millisecond-1)/time.Millisecond))
+	}
+
+	err = s.with(ctx, func(c *conn) error {
+		if _, err := c.do("WATCH", key); err != nil {
+			return err
+		}
+		v, err := c.do("GET", key)
+		if err != nil {
+			return err
+		}
+		var cur cooldown.Record
+		if !v.Nil {
+			if err = cur.UnmarshalBinary(v.Str); err != nil {
+				return err
+			}
+		}
+		if cur.Version != version {
+			if _, err = c.do("UNWATCH"); err != nil {
+				return err
+			}
+			return cooldown.ErrVersionConflict
+		}
+		// The transaction is aborted if the key was changed since WATCH, or
+		// expired.
+		replies, err := c.pipeline([]any{"MULTI"}, set, []any{"EXEC"})
+		if err != nil {
+			return err
+		}
+		if replies[2].Nil {
+			return cooldown.ErrVersionConflict
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return rec.Version, nil
+}
+
+// ttl returns expiration of the record in Redis. Ok is false if the record
+// must be kept until it is deleted.
+func (s *Store) ttl(rec cooldown.Record) (time.Duration, bool) {
+	state := rec.StateAt(s.clock.Now())
+	switch {
+	case state.Paused:
+		return 0, false
+	case state.Active:
+		return rec.RemainingAt(s.clock.Now()) + s.retention + time.Millisecond, true
+	default:
+		return s.retention, s.retention > 0
+	}
+}
+
+// Delete ...
+func (s *Store) Delete(ctx context.Context, key string) error {
+	return s.with(ctx, func(c *conn) error {
+		_, err := c.do("DEL", key)
+		return err
+	})
+}
+
+// ScanPrefix ...
+func (s *Store) ScanPrefix(ctx context.Context, prefix string, fn func(key string, rec cooldown.Record) bool) error {
+	var keys []string
+	err := s.with(ctx, func(c *conn) error {
+		cursor := "0"
+		for {
+			v, err := c.do("SCAN", cursor, "MATCH", escapeGlob(prefix)+"*", "COUNT", 100)
+			if err != nil {
+				return err
+			}
+			if len(v.Array) != 2 {
+				return resp.ErrProtocol
+			}
+			for _, key := range v.Array[1].Array {
+				keys = append(keys, string(key.Str))
+			}
+			if cursor = string(v.Array[0].Str); cursor == "0" {
+				return nil
+			}
+		}
+	})
+	if err != nil {
+		return err
+	}
+	// SCAN may return the same key several times.
+	slices.Sort(keys)
+	keys = slices.Compact(keys)
+
+	for _, key := range keys {
+		rec, ok, err := s.Get(ctx, key)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			// Deleted or expired during the scan.
+			continue
+		}
+		if !fn(key, rec) {
+			return nil
+		}
+	}
+	return nil
+}
+
+// Close closes idle connections.
+func (s *Store) Close() error {
+	var errs []error
+	for {
+		select {
+		case c := <-s.pool:
+			errs = append(errs, c.Close())
+		default:
+			return errors.Join(errs...)
+		}
+	}
+}
+
+// with calls fn with idle or new connection. Connection is reused only if fn
+// succeeded or failed with version conflict, so no WATCH is left on it.
+func (s *Store) with(ctx context.Context, fn func(c *conn) error) error {
+	var c *conn
+	select {
+	case c = <-s.pool:
+	default:
+		var err error
+		if c, err = s.dial(ctx); err != nil {
+			return err
+		}
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = c.SetDeadline(deadline)
+	} else {
+		_ = c.SetDeadline(time.Time{})
+	}
+
+	err := fn(c)
+	if err != nil && !errors.Is(err, cooldown.ErrVersionConflict) {
+		_ = c.Close()
+		return err
+	}
+	select {
+	case s.pool <- c:
+	default:
+		_ = c.Close()
+	}
+	return err
+}
+
+func (s *Store) dial(ctx context.Context) (*conn, error) {
+	nc, err := s.dialer.DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return nil, err
+	}
+	c := newConn(nc)
+	if s.password != "" {
+		if _, err = c.do("AUTH", s.password); err != nil {
+			_ = c.Close()
+			return nil, err
+		}
+	}
+	if s.db != 0 {
+		if _, err = c.do("SELECT", s.db); err != nil {
+			_ = c.Close()
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// conn is the connection to the Redis server.
+type conn struct {
+	net.Conn
+	r *resp.Reader
+	w *resp.Writer
+}
+
+func newConn(nc net.Conn) *conn {
+	return &conn{Conn: nc, r: resp.NewReader(nc), w: resp.NewWriter(nc)}
+}
+
+// do sends the command and reads its reply.
+func (c *conn) do(args ...any) (resp.Value, error) {
+	replies, err := c.pipeline(args)
+	if err != nil {
+		return resp.Value{}, err
+	}
+	return replies[0], nil
+}
+
+// pipeline sends the commands at once and reads their replies. The first
+// error reply is returned as error.
+func (c *conn) pipeline(commands ...[]any) ([]resp.Value, error) {
+	for _, args := range commands {
+		c.w.Command(args...)
+	}
+	if err := c.w.Flush(); err != nil {
+		return nil, err
+	}
+	var (
+		replies  = make([]resp.Value, len(commands))
+		replyErr error
+	)
+	// All the replies are read even if some of them are errors, so the
+	// connection can be reused.
+	for i := range replies {
+		v, err := c.r.Read()
+		if err != nil {
+			return nil, err
+		}
+		if replyErr == nil {
+			replyErr = v.Err()
+		}
+		replies[i] = v
+	}
+	return replies, replyErr
+}
+
+// escapeGlob escapes special characters of the SCAN MATCH pattern. The key is
+// escaped byte by byte, so invalid UTF-8 is kept as is.
+func escapeGlob(s string) string {
+	var b strings.Builder
+	for i := range len(s) {
+		if strings.IndexByte(`*?[]\`, s[i]) >= 0 {
+			b.WriteByte('\\')
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
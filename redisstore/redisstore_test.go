@@ -0,0 +1,150 @@
+package redisstore_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/k4ties/cooldown"
+	"github.com/k4ties/cooldown/redisstore"
+	"github.com/k4ties/cooldown/redisstore/redistest"
+	"github.com/k4ties/cooldown/storetest"
+)
+
+func newStore(t *testing.T, opts ...redisstore.Option) (*redisstore.Store, *redistest.Server) {
+	t.Helper()
+	srv := redistest.NewServer()
+	s := redisstore.New(srv.Addr(), opts...)
+	t.Cleanup(func() {
+		_ = s.Close()
+		_ = srv.Close()
+	})
+	return s, srv
+}
+
+func TestStore(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) cooldown.Store {
+		s, _ := newStore(t, redisstore.OptionPassword("secret"), redisstore.OptionDB(1))
+		return s
+	})
+}
+
+func TestExpiration(t *testing.T) {
+	ctx := context.Background()
+	s, srv := newStore(t)
+	reg := cooldown.NewStoredRegistry[string](s, "cd/")
+
+	if err := reg.Start(ctx, "a", time.Second*10, "value"); err != nil {
+		t.Fatal(err)
+	}
+	if ttl, ok := srv.TTL("cd/a"); !ok || ttl <= time.Second*9 || ttl > time.Second*11 {
+		t.Fatalf("TTL = %s, %t, want about 10s", ttl, ok)
+	}
+
+	// Paused cooldown never expires
+	if paused, err := reg.Pause(ctx, "a"); err != nil || !paused {
+		t.Fatalf("Pause() = %t, %v", paused, err)
+	}
+	if _, ok := srv.TTL("cd/a"); ok {
+		t.Fatal("paused record has TTL")
+	}
+	if resumed, err := reg.Resume(ctx, "a"); err != nil || !resumed {
+		t.Fatalf("Resume() = %t, %v", resumed, err)
+	}
+
+	srv.FastForward(time.Second * 11)
+	if _, ok, err := s.Get(ctx, "cd/a"); err != nil || ok {
+		t.Fatalf("Get(expired) = %t, %v, want false, nil", ok, err)
+	}
+	if err := reg.TryStart(ctx, "a", time.Second, "value"); err != nil {
+		t.Fatalf("TryStart after expiration: %v", err)
+	}
+
+	// Stopped record is kept without retention
+	if err := reg.Stop(ctx, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, _ := s.Get(ctx, "cd/a"); !ok {
+		t.Fatal("stopped record was removed")
+	}
+	if _, ok := srv.TTL("cd/a"); ok {
+		t.Fatal("stopped record has TTL")
+	}
+}
+
+func TestRetention(t *testing.T) {
+	ctx := context.Background()
+	s, srv := newStore(t, redisstore.OptionRetention(time.Minute))
+	reg := cooldown.NewStoredRegistry[string](s, "")
+
+	if err := reg.Start(ctx, "a", time.Second, ""); err != nil {
+		t.Fatal(err)
+	}
+	if ttl, _ := srv.TTL("a"); ttl <= time.Minute {
+		t.Fatalf("TTL = %s, want more than a minute", ttl)
+	}
+	if err := reg.Stop(ctx, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if ttl, ok := srv.TTL("a"); !ok || ttl > time.Minute {
+		t.Fatalf("TTL = %s, %t, want a minute", ttl, ok)
+	}
+	srv.FastForward(time.Minute)
+	if _, ok, _ := s.Get(ctx, "a"); ok {
+		t.Fatal("stopped record was not removed after retention")
+	}
+}
+
+func TestRetentionRounding(t *testing.T) {
+	ctx := context.Background()
+	s, srv := newStore(t, redisstore.OptionRetention(time.Microsecond*500))
+	reg := cooldown.NewStoredRegistry[string](s, "")
+
+	if err := reg.Start(ctx, "a", time.Second, ""); err != nil {
+		t.Fatal(err)
+	}
+	// Retention below a millisecond is rounded up, not sent as PX 0.
+	if err := reg.Stop(ctx, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if ttl, ok := srv.TTL("a"); !ok || ttl <= 0 || ttl > time.Millisecond {
+		t.Fatalf("TTL = %s, %t, want a millisecond", ttl, ok)
+	}
+}
+
+func TestScanPrefixGlob(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newStore(t)
+	for _, key := range []string{"a*b", "a*bc", "axb"} {
+		if _, err := s.CompareAndSet(ctx, key, 0, storetest.Record(cooldown.OpStart, key)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var keys []string
+	if err := s.ScanPrefix(ctx, "a*", func(key string, _ cooldown.Record) bool {
+		keys = append(keys, key)
+		return true
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 2 || keys[0] != "a*b" || keys[1] != "a*bc" {
+		t.Fatalf("keys = %v, want [a*b a*bc]", keys)
+	}
+
+	// Invalid UTF-8 in the prefix is matched byte by byte.
+	for _, key := range []string{"\xff*x", "\xffyx"} {
+		if _, err := s.CompareAndSet(ctx, key, 0, storetest.Record(cooldown.OpStart, key)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	keys = nil
+	if err := s.ScanPrefix(ctx, "\xff*", func(key string, _ cooldown.Record) bool {
+		keys = append(keys, key)
+		return true
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 1 || keys[0] != "\xff*x" {
+		t.Fatalf("keys = %q, want [\"\\xff*x\"]", keys)
+	}
+}
@@ -0,0 +1,424 @@
+// Package redistest provides minimal in-process Redis server for tests, so
+// code using Redis can be tested offline.
+//
+// The server supports only commands used by the redisstore package: PING,
+// AUTH, SELECT, GET, SET (with PX and EX), DEL, PTTL, SCAN (with MATCH and
+// COUNT) and transactions with WATCH, UNWATCH, MULTI, EXEC and DISCARD. AUTH
+// and SELECT are accepted, but ignored. Keys expire lazily, according to the
+// virtual clock of the server, see FastForward.
+package redistest
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/k4ties/cooldown/internal/resp"
+)
+
+// Server is the in-process Redis server.
+type Server struct {
+	ln net.Listener
+	wg sync.WaitGroup
+
+	mu     sync.Mutex
+	data   map[string]entry
+	revs   map[string]uint64
+	rev    uint64
+	offset time.Duration
+	conns  map[net.Conn]struct{}
+	closed bool
+}
+
+type entry struct {
+	value    []byte
+	expireAt time.Time
+}
+
+// NewServer starts new server listening on the loopback interface. It panics
+// if it can't listen, like httptest.NewServer.
+func NewServer() *Server {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic(fmt.Sprintf("redistest: failed to listen: %v", err))
+	}
+	s := &Server{
+		ln:    ln,
+		data:  make(map[string]entry),
+		revs:  make(map[string]uint64),
+		conns: make(map[net.Conn]struct{}),
+	}
+	s.wg.Add(1)
+	go s.serve()
+	return s
+}
+
+// Addr returns the address the server listens on.
+func (s *Server) Addr() string {
+	return s.ln.Addr().String()
+}
+
+// FastForward moves the clock of the server forward, so keys expire without
+// waiting.
+func (s *Server) FastForward(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.offset += d
+}
+
+// TTL returns time until the key expires. Ok is false if the key doesn't
+// exist or has no expiration.
+func (s *Server) TTL(key string) (ttl time.Duration, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.getUnsafe(key)
+	if !ok || e.expireAt.IsZero() {
+		return 0, false
+	}
+	return e.expireAt.Sub(s.nowUnsafe()), true
+}
+
+// Close stops the server and closes all the connections.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	s.closed = true
+	for c := range s.conns {
+		_ = c.Close()
+	}
+	s.mu.Unlock()
+	err := s.ln.Close()
+	s.wg.Wait()
+	return err
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+	for {
+		c, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			_ = c.Close()
+			return
+		}
+		s.conns[c] = struct{}{}
+		s.mu.Unlock()
+
+		s.wg.Add(1)
+		go s.handle(c)
+	}
+}
+
+// session is the state of the client connection.
+type session struct {
+	w       *resp.Writer
+	watched map[string]uint64
+	multi   bool
+	queued  [][]string
+	// aborted is set if the queued command was invalid.
+	aborted bool
+}
+
+func (s *Server) handle(c net.Conn) {
+	defer s.wg.Done()
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, c)
+		s.mu.Unlock()
+		_ = c.Close()
+	}()
+
+	r := resp.NewReader(c)
+	sess := &session{w: resp.NewWriter(c), watched: make(map[string]uint64)}
+	for {
+		v, err := r.Read()
+		if err != nil {
+			if !errors.Is(err, io.EOF) && !errors.Is(err, net.ErrClosed) {
+				sess.w.Error("ERR " + err.Error())
+				_ = sess.w.Flush()
+			}
+			return
+		}
+		if v.Kind != resp.Array || len(v.Array) == 0 {
+			sess.w.Error("ERR unknown request")
+		} else {
+			args := make([]string, len(v.Array))
+			for i, arg := range v.Array {
+				args[i] = string(arg.Str)
+			}
+			args[0] = strings.ToUpper(args[0])
+			s.command(sess, args)
+		}
+		if err = sess.w.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func (s *Server) command(sess *session, args []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if sess.multi {
+		switch args[0] {
+		case "EXEC":
+			s.execUnsafe(sess)
+		case "DISCARD":
+			sess.multi, sess.queued, sess.aborted = false, nil, false
+			clear(sess.watched)
+			sess.w.Simple("OK")
+		case "MULTI", "WATCH":
+			sess.w.Error("ERR " + args[0] + " inside MULTI is not allowed")
+		default:
+			if !known(args[0]) {
+				sess.aborted = true
+				sess.w.Error("ERR unknown command '" + args[0] + "'")
+				return
+			}
+			sess.queued = append(sess.queued, args)
+			sess.w.Simple("QUEUED")
+		}
+		return
+	}
+
+	switch args[0] {
+	case "MULTI":
+		sess.multi = true
+		sess.w.Simple("OK")
+	case "EXEC", "DISCARD":
+		sess.w.Error("ERR " + args[0] + " without MULTI")
+	case "WATCH":
+		for _, key := range args[1:] {
+			s.getUnsafe(key)
+			sess.watched[key] = s.revs[key]
+		}
+		sess.w.Simple("OK")
+	case "UNWATCH":
+		clear(sess.watched)
+		sess.w.Simple("OK")
+	default:
+		s.runUnsafe(sess.w, args)
+	}
+}
+
+// execUnsafe executes the queued commands, unless any watched key was
+// changed.
+func (s *Server) execUnsafe(sess *session) {
+	defer func() {
+		sess.multi, sess.queued, sess.aborted = false, nil, false
+		clear(sess.watched)
+	}()
+	if sess.aborted {
+		sess.w.Error("EXECABORT Transaction discarded because of previous errors.")
+		return
+	}
+	for key, rev := range sess.watched {
+		s.getUnsafe(key)
+		if s.revs[key] != rev {
+			sess.w.NilArray()
+			return
+		}
+	}
+	sess.w.ArrayHeader(len(sess.queued))
+	for _, args := range sess.queued {
+		s.runUnsafe(sess.w, args)
+	}
+}
+
+func known(cmd string) bool {
+	switch cmd {
+	case "PING", "AUTH", "SELECT", "GET", "SET", "DEL", "PTTL", "SCAN", "UNWATCH":
+		return true
+	}
+	return false
+}
+
+// runUnsafe runs the regular command.
+func (s *Server) runUnsafe(w *resp.Writer, args []string) {
+	switch args[0] {
+	case "PING":
+		w.Simple("PONG")
+	case "AUTH", "SELECT":
+		w.Simple("OK")
+	case "GET":
+		if len(args) != 2 {
+			w.Error("ERR wrong number of arguments for 'get' command")
+			return
+		}
+		if e, ok := s.getUnsafe(args[1]); ok {
+			w.Bulk(e.value)
+			return
+		}
+		w.Nil()
+	case "SET":
+		s.setUnsafe(w, args)
+	case "DEL":
+		var n int64
+		for _, key := range args[1:] {
+			if _, ok := s.getUnsafe(key); ok {
+				delete(s.data, key)
+				s.touchUnsafe(key)
+				n++
+			}
+		}
+		w.Int(n)
+	case "PTTL":
+		if len(args) != 2 {
+			w.Error("ERR wrong number of arguments for 'pttl' command")
+			return
+		}
+		e, ok := s.getUnsafe(args[1])
+		switch {
+		case !ok:
+			w.Int(-2)
+		case e.expireAt.IsZero():
+			w.Int(-1)
+		default:
+			w.Int(e.expireAt.Sub(s.nowUnsafe()).Milliseconds())
+		}
+	case "SCAN":
+		s.scanUnsafe(w, args)
+	case "UNWATCH":
+		w.Simple("OK")
+	default:
+		w.Error("ERR unknown command '" + args[0] + "'")
+	}
+}
+
+func (s *Server) setUnsafe(w *resp.Writer, args []string) {
+	if len(args) < 3 {
+		w.Error("ERR wrong number of arguments for 'set' command")
+		return
+	}
+	e := entry{value: []byte(args[2])}
+	for i := 3; i < len(args); i++ {
+		opt := strings.ToUpper(args[i])
+		if (opt != "PX" && opt != "EX") || i+1 >= len(args) {
+			w.Error("ERR syntax error")
+			return
+		}
+		n, err := strconv.ParseInt(args[i+1], 10, 64)
+		if err != nil || n <= 0 {
+			w.Error("ERR invalid expire time in 'set' command")
+			return
+		}
+		unit := time.Millisecond
+		if opt == "EX" {
+			unit = time.Second
+		}
+		e.expireAt = s.nowUnsafe().Add(time.Duration(n) * unit)
+		i++
+	}
+	s.data[args[1]] = e
+	s.touchUnsafe(args[1])
+	w.Simple("OK")
+}
+
+func (s *Server) scanUnsafe(w *resp.Writer, args []string) {
+	if len(args) < 2 {
+		w.Error("ERR wrong number of arguments for 'scan' command")
+		return
+	}
+	cursor, err := strconv.Atoi(args[1])
+	if err != nil || cursor < 0 {
+		w.Error("ERR invalid cursor")
+		return
+	}
+	pattern, count := "*", 10
+	for i := 2; i+1 < len(args); i += 2 {
+		switch strings.ToUpper(args[i]) {
+		case "MATCH":
+			pattern = args[i+1]
+		case "COUNT":
+			if count, err = strconv.Atoi(args[i+1]); err != nil || count <= 0 {
+				w.Error("ERR syntax error")
+				return
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(s.data))
+	for key := range s.data {
+		if _, ok := s.getUnsafe(key); ok {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+	end, next := min(cursor+count, len(keys)), cursor+count
+	if next >= len(keys) {
+		next = 0
+	}
+	var matched []string
+	for _, key := range keys[min(cursor, len(keys)):end] {
+		if match(pattern, key) {
+			matched = append(matched, key)
+		}
+	}
+	w.ArrayHeader(2)
+	w.Bulk([]byte(strconv.Itoa(next)))
+	w.ArrayHeader(len(matched))
+	for _, key := range matched {
+		w.Bulk([]byte(key))
+	}
+}
+
+// getUnsafe returns the entry, removing it if it is expired.
+func (s *Server) getUnsafe(key string) (entry, bool) {
+	e, ok := s.data[key]
+	if ok && !e.expireAt.IsZero() && !e.expireAt.After(s.nowUnsafe()) {
+		delete(s.data, key)
+		s.touchUnsafe(key)
+		return entry{}, false
+	}
+	return e, ok
+}
+
+// touchUnsafe marks the key as changed, so transactions watching it fail.
+func (s *Server) touchUnsafe(key string) {
+	s.rev++
+	s.revs[key] = s.rev
+}
+
+func (s *Server) nowUnsafe() time.Time {
+	return time.Now().Add(s.offset)
+}
+
+// match reports whether the key matches the glob pattern. Only *, ? and
+// backslash escapes are supported.
+func match(pattern, key string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			for i := len(key); i >= 0; i-- {
+				if match(pattern[1:], key[i:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if len(key) == 0 {
+				return false
+			}
+		case '\\':
+			if len(pattern) > 1 {
+				pattern = pattern[1:]
+			}
+			fallthrough
+		default:
+			if len(key) == 0 || key[0] != pattern[0] {
+				return false
+			}
+		}
+		pattern, key = pattern[1:], key[1:]
+	}
+	return len(key) == 0
+}
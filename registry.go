@@ -0,0 +1,263 @@
+package cooldown
+
+import (
+	"sync"
+	"time"
+)
+
+// Registry is keyed collection of Valued cooldowns. Cooldowns are created
+// lazily on first access, and constraints registered in the Registry are
+// checked every time cooldown is started, either via the Registry or directly.
+//
+// Cooldowns of the registry lock it while they are started or expire, and the
+// registry is locked while it stops them, so their handlers must use only
+// Unsafe methods of the registry. Unsafe start methods of such cooldowns must
+// be called with the registry locked.
+type Registry[K comparable, T any] struct {
+	mu sync.RWMutex
+
+	cooldowns   map[K]*Valued[T]
+	opts        []ValuedOption[T]
+	constraints []Constraint[K, T]
+
+	tolerances map[K]time.Duration
+	tolerated  map[K]ToleranceStats
+
+	violations *ViolationRecorder[K]
+	hlc        *HLC
+}
+
+// NewRegistry creates new Registry.
+func NewRegistry[K comparable, T any](opts ...RegistryOption[K, T]) *Registry[K, T] {
+	reg := &Registry[K, T]{
+		cooldowns:  make(map[K]*Valued[T]),
+		tolerances: make(map[K]time.Duration),
+		tolerated:  make(map[K]ToleranceStats),
+	}
+	for _, opt := range opts {
+		if opt == nil {
+			continue
+		}
+		opt(reg)
+	}
+	return reg
+}
+
+// Get returns cooldown with provided key, creating it if it doesn't exist.
+func (reg *Registry[K, T]) Get(key K) *Valued[T] {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	return reg.GetUnsafe(key)
+}
+
+func (reg *Registry[K, T]) GetUnsafe(key K) *Valued[T] {
+	cd, ok := reg.cooldowns[key]
+	if !ok {
+		cd = reg.newValued(key)
+		reg.cooldowns[key] = cd
+	}
+	return cd
+}
+
+// newValued creates cooldown with provided key without adding it to the
+// registry.
+func (reg *Registry[K, T]) newValued(key K) *Valued[T] {
+	cd := NewValued[T](reg.opts...)
+	if hlc := reg.hlc; hlc != nil {
+		cd.stamp = hlc.Now
+	}
+	if rec := reg.violations; rec != nil {
+		cd.violation = func(early time.Duration) {
+			rec.Record(key, early)
+		}
+	}
+	cd.owner = &reg.mu
+	cd.check = func(val T) error {
+		return reg.CheckUnsafe(key, val)
+	}
+	return cd
+}
+
+// Lookup returns cooldown with provided key, if it exists.
+func (reg *Registry[K, T]) Lookup(key K) (*Valued[T], bool) {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+	return reg.LookupUnsafe(key)
+}
+
+func (reg *Registry[K, T]) LookupUnsafe(key K) (*Valued[T], bool) {
+	cd, ok := reg.cooldowns[key]
+	return cd, ok
+}
+
+// Delete stops cooldown with provided key and removes it from the registry.
+func (reg *Registry[K, T]) Delete(key K, val T) {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	reg.DeleteUnsafe(key, val)
+}
+
+func (reg *Registry[K, T]) DeleteUnsafe(key K, val T) {
+	if cd, ok := reg.cooldowns[key]; ok {
+		cd.Stop(val)
+		delete(reg.cooldowns, key)
+	}
+	delete(reg.tolerances, key)
+	delete(reg.tolerated, key)
+}
+
+// Start checks constraints of the registry and starts cooldown with provided
+// key. If any constraint rejects the start, its error is returned (usually
+// *ConstraintError). ErrStartCancelled is returned if the start was cancelled
+// by the cooldown handler.
+func (reg *Registry[K, T]) Start(key K, dur time.Duration, val T) error {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	return reg.StartUnsafe(key, dur, val)
+}
+
+func (reg *Registry[K, T]) StartUnsafe(key K, dur time.Duration, val T) error {
+	return reg.GetUnsafe(key).start(dur, val)
+}
+
+// TryStart starts cooldown with provided key only if it is not active. It
+// returns *ErrOnCooldown if it is active, otherwise it behaves like Start.
+// Registry is locked for the whole call, so concurrent callers can't start the
+// same cooldown twice.
+func (reg *Registry[K, T]) TryStart(key K, dur time.Duration, val T) error {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	return reg.TryStartUnsafe(key, dur, val)
+}
+
+func (reg *Registry[K, T]) TryStartUnsafe(key K, dur time.Duration, val T) error {
+	_, _, err := reg.tryStartUnsafe(key, dur, val)
+	return err
+}
+
+// tryStartUnsafe is TryStartUnsafe that also returns the started cooldown and
+// generation of the start.
+func (reg *Registry[K, T]) tryStartUnsafe(key K, dur time.Duration, val T) (*Valued[T], uint64, error) {
+	cd := reg.GetUnsafe(key)
+	cd.mu.Lock()
+	defer cd.mu.Unlock()
+	if cd.ActiveUnsafe() {
+		remaining := cd.RemainingUnsafe()
+		reg.violateUnsafe(key, remaining)
+		return nil, 0, &ErrOnCooldown{Remaining: remaining}
+	}
+	if err := cd.startUnsafe(dur, val); err != nil {
+		return nil, 0, err
+	}
+	return cd, cd.gen, nil
+}
+
+// violateUnsafe records violation of the key, if registry has
+// ViolationRecorder.
+func (reg *Registry[K, T]) violateUnsafe(key K, early time.Duration) {
+	if reg.violations != nil {
+		reg.violations.Record(key, early)
+	}
+}
+
+// Violations returns ViolationRecorder of the registry, or nil if it wasn't
+// set.
+func (reg *Registry[K, T]) Violations() *ViolationRecorder[K] {
+	return reg.violations
+}
+
+// Check checks all constraints of the registry for the cooldown with provided
+// key without starting it.
+func (reg *Registry[K, T]) Check(key K, val T) error {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+	return reg.CheckUnsafe(key, val)
+}
+
+func (reg *Registry[K, T]) CheckUnsafe(key K, val T) error {
+	for _, c := range reg.constraints {
+		if err := c(reg, key, val); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Constrain registers constraints checked on every Start.
+func (reg *Registry[K, T]) Constrain(constraints ...Constraint[K, T]) {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	for _, c := range constraints {
+		if c != nil {
+			reg.constraints = append(reg.constraints, c)
+		}
+	}
+}
+
+// Stop stops cooldown with provided key. Like in Delete, HandleStop is called
+// while the registry is locked.
+func (reg *Registry[K, T]) Stop(key K, val T) {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	reg.StopUnsafe(key, val)
+}
+
+func (reg *Registry[K, T]) StopUnsafe(key K, val T) {
+	if cd, ok := reg.cooldowns[key]; ok {
+		cd.Stop(val)
+	}
+}
+
+// Active returns true if cooldown with provided key is active.
+func (reg *Registry[K, T]) Active(key K) bool {
+	cd, ok := reg.Lookup(key)
+	return ok && cd.Active()
+}
+
+// Remaining returns duration until cooldown with provided key expires.
+func (reg *Registry[K, T]) Remaining(key K) time.Duration {
+	if cd, ok := reg.Lookup(key); ok {
+		return cd.Remaining()
+	}
+	return 0
+}
+
+// Prune removes inactive cooldowns, so they are created again on the next
+// access. Tolerances and their statistics are kept.
+//
+// Removed cooldowns forget when they expired, so Requires constraints that
+// depend on them reject the starts until they are started and expire again.
+// Don't prune registries with such constraints more often than their
+// windows.
+func (reg *Registry[K, T]) Prune() {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	for key, cd := range reg.cooldowns {
+		if !cd.Active() {
+			delete(reg.cooldowns, key)
+		}
+	}
+}
+
+// Len returns count of cooldowns in the registry.
+func (reg *Registry[K, T]) Len() int {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+	return len(reg.cooldowns)
+}
+
+// Range calls fn for every cooldown in the registry, until it returns false.
+// Registry is locked during the iteration.
+func (reg *Registry[K, T]) Range(fn func(key K, cd *Valued[T]) bool) {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+	for key, cd := range reg.cooldowns {
+		if !fn(key, cd) {
+			return
+		}
+	}
+}
+
+func (reg *Registry[K, T]) L() *sync.RWMutex {
+	return &reg.mu
+}
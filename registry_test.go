@@ -0,0 +1,139 @@
+package cooldown_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+	"github.com/k4ties/cooldown"
+)
+
+func TestRegistry(t *testing.T) {
+	reg := cooldown.NewRegistry[string, struct{}]()
+	assert.Equal(t, reg.Active("a"), false)
+	assert.Equal(t, reg.Len(), 0)
+
+	assert.Equal(t, reg.Start("a", time.Second, struct{}{}), nil)
+	assert.Equal(t, reg.Active("a"), true)
+	assert.Equal(t, reg.Len(), 1)
+	assert.Equal(t, reg.Start("b", 0, struct{}{}), cooldown.ErrStartCancelled)
+
+	reg.Delete("a", struct{}{})
+	assert.Equal(t, reg.Active("a"), false)
+	assert.Equal(t, reg.Len(), 1)
+
+	assert.Equal(t, reg.Start("a", time.Second, struct{}{}), nil)
+	reg.Prune()
+	assert.Equal(t, reg.Len(), 1)
+	assert.Equal(t, reg.Active("a"), true)
+}
+
+func TestRegistryConstraints(t *testing.T) {
+	t.Run("exclusive", func(t *testing.T) {
+		reg := cooldown.NewRegistry(cooldown.RegistryOptionConstraint(
+			cooldown.Exclusive[string, struct{}]("shield", "dash"),
+		))
+		assert.Equal(t, reg.Start("dash", time.Second, struct{}{}), nil)
+
+		err := reg.Start("shield", time.Second, struct{}{})
+		var cErr *cooldown.ConstraintError[string]
+		assert.Equal(t, errors.As(err, &cErr), true)
+		assert.Equal(t, cErr.Reason, cooldown.ReasonExcluded)
+		assert.Equal(t, cErr.Other, "dash")
+		assert.Equal(t, cErr.Remaining > 0, true)
+
+		reg.Stop("dash", struct{}{})
+		assert.Equal(t, reg.Start("shield", time.Second, struct{}{}), nil)
+		assert.NotEqual(t, reg.Start("dash", time.Second, struct{}{}), nil)
+
+		// Cooldowns started directly are checked too.
+		dash := reg.Get("dash")
+		assert.Equal(t, dash.Start(time.Second, struct{}{}), false)
+		started, _ := dash.TryStart(time.Second, struct{}{})
+		assert.Equal(t, started, false)
+		_, err = dash.StartQueued(time.Second, struct{}{})
+		assert.Equal(t, errors.As(err, &cErr), true)
+		assert.Equal(t, dash.Active(), false)
+	})
+	t.Run("requires", func(t *testing.T) {
+		reg := cooldown.NewRegistry(cooldown.RegistryOptionConstraint(
+			cooldown.Requires[string, struct{}]("ultimate", "charge", time.Millisecond*50),
+		))
+		var cErr *cooldown.ConstraintError[string]
+		assert.Equal(t, errors.As(reg.Start("ultimate", time.Second, struct{}{}), &cErr), true)
+		assert.Equal(t, cErr.Reason, cooldown.ReasonRequired)
+
+		assert.Equal(t, reg.Start("charge", time.Millisecond*5, struct{}{}), nil)
+		assert.NotEqual(t, reg.Check("ultimate", struct{}{}), nil)
+		<-time.After(time.Millisecond * 20)
+		assert.Equal(t, reg.Check("ultimate", struct{}{}), nil)
+		<-time.After(time.Millisecond * 50)
+		assert.NotEqual(t, reg.Check("ultimate", struct{}{}), nil)
+	})
+	t.Run("self", func(t *testing.T) {
+		reg := cooldown.NewRegistry(cooldown.RegistryOptionConstraint(
+			cooldown.Excludes[string, struct{}]("a", "a"),
+			cooldown.Requires[string, struct{}]("a", "a", time.Second),
+		))
+		// Constraints of the cooldown on itself are ignored.
+		assert.Equal(t, reg.Start("a", time.Second, struct{}{}), nil)
+		assert.Equal(t, reg.Get("a").Start(time.Second, struct{}{}), true)
+	})
+}
+
+// lockHandler records whether the registry was locked on stops.
+type lockHandler struct {
+	cooldown.NopValuedHandler[struct{}]
+	reg    **cooldown.Registry[string, struct{}]
+	locked []bool
+}
+
+func (h *lockHandler) HandleStop(*cooldown.Valued[struct{}], cooldown.StopCause, struct{}) {
+	l := (*h.reg).L()
+	locked := !l.TryLock()
+	if !locked {
+		l.Unlock()
+	}
+	h.locked = append(h.locked, locked)
+}
+
+func TestRegistryStopLocked(t *testing.T) {
+	var reg *cooldown.Registry[string, struct{}]
+	h := &lockHandler{reg: &reg}
+	reg = cooldown.NewRegistry(cooldown.RegistryOptionValued[string](cooldown.ValuedOptionHandler[struct{}](h)))
+
+	// Stop and Delete call the handler in the same lock context.
+	assert.Equal(t, reg.Start("a", time.Second, struct{}{}), nil)
+	reg.Stop("a", struct{}{})
+	assert.Equal(t, reg.Start("a", time.Second, struct{}{}), nil)
+	reg.Delete("a", struct{}{})
+	assert.Equal(t, h.locked, []bool{true, true})
+}
+
+func TestRegistryTryStart(t *testing.T) {
+	reg := cooldown.NewRegistry[string, struct{}]()
+	assert.Equal(t, reg.TryStart("a", time.Second, struct{}{}), nil)
+
+	var onCooldown *cooldown.ErrOnCooldown
+	assert.Equal(t, errors.As(reg.TryStart("a", time.Second, struct{}{}), &onCooldown), true)
+	assert.Equal(t, onCooldown.Remaining > 0, true)
+}
+
+func TestRegistryTolerance(t *testing.T) {
+	reg := cooldown.NewRegistry[string, struct{}]()
+	reg.SetTolerance("player", time.Millisecond*50)
+	assert.Equal(t, reg.Tolerance("player"), time.Millisecond*50)
+
+	assert.Equal(t, reg.TryStartWithTolerance("player", time.Millisecond*20, struct{}{}), nil)
+	assert.Equal(t, reg.Tolerated("player").Count, 0)
+	assert.Equal(t, reg.ReadyWithTolerance("player"), true)
+
+	assert.Equal(t, reg.TryStartWithTolerance("player", time.Second, struct{}{}), nil)
+	stats := reg.Tolerated("player")
+	assert.Equal(t, stats.Count, 1)
+	assert.Equal(t, stats.Max > 0 && stats.Max <= time.Millisecond*20, true)
+
+	var onCooldown *cooldown.ErrOnCooldown
+	assert.Equal(t, errors.As(reg.TryStartWithTolerance("player", time.Second, struct{}{}), &onCooldown), true)
+}
@@ -0,0 +1,265 @@
+package cooldown
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// Merge merges states of the same cooldown changed independently, e.g. by
+// two servers during a network partition. The merge is commutative,
+// associative and idempotent, so replicas converge regardless of the order
+// they exchange states in:
+//
+//   - The state with the latest expiration wins.
+//   - If expirations are equal, the state paused later wins, and paused state
+//     wins over not paused one.
+//
+// Stops are not replicated by Merge, since the stopped state has no
+// expiration. States without timestamps can't tell which pause or resume
+// happened later, see ReplicaState for the merge that can.
+func Merge(a, b BasicState) BasicState {
+	switch {
+	case a.Expiration.After(b.Expiration):
+		return a
+	case a.Expiration.Before(b.Expiration):
+		return b
+	case a.PausedDate.After(b.PausedDate):
+		return a
+	case a.PausedDate.Before(b.PausedDate):
+		return b
+	}
+	// Flags may differ only if the states were taken at different times.
+	a.Active = a.Active || b.Active
+	a.Paused = a.Paused || b.Paused
+	return a
+}
+
+// Timestamp is the hybrid logical timestamp: the physical time extended with
+// logical counter, so timestamps of causally related events are ordered even
+// if clocks of the nodes are skewed. Node is the tiebreaker of concurrent
+// events with the same time.
+type Timestamp struct {
+	Wall    int64
+	Logical uint32
+	Node    string
+}
+
+// IsZero returns true if the timestamp is zero.
+func (ts Timestamp) IsZero() bool {
+	return ts == Timestamp{}
+}
+
+// Compare returns -1 if ts is before other, 1 if it is after, and 0 if they
+// are equal.
+func (ts Timestamp) Compare(other Timestamp) int {
+	switch {
+	case ts.Wall != other.Wall:
+		return cmpInt(ts.Wall, other.Wall)
+	case ts.Logical != other.Logical:
+		return cmpInt(ts.Logical, other.Logical)
+	}
+	return strings.Compare(ts.Node, other.Node)
+}
+
+func cmpInt[N int64 | uint32](a, b N) int {
+	if a < b {
+		return -1
+	}
+	return 1
+}
+
+// HLC is the hybrid logical clock of the node.
+type HLC struct {
+	mu    sync.Mutex
+	node  string
+	clock Clock
+	last  Timestamp
+}
+
+// NewHLC creates new hybrid logical clock of the node. Nil clock means
+// SystemClock.
+func NewHLC(node string, clock Clock) *HLC {
+	if clock == nil {
+		clock = SystemClock{}
+	}
+	return &HLC{node: node, clock: clock}
+}
+
+// Now returns timestamp of the new local event. Timestamps returned by Now
+// always increase.
+func (c *HLC) Now() Timestamp {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if wall := c.clock.Now().UnixNano(); wall > c.last.Wall {
+		c.last = Timestamp{Wall: wall, Node: c.node}
+	} else {
+		c.last.Logical++
+	}
+	return c.last
+}
+
+// Observe updates the clock with timestamp received from another node, so
+// the next local timestamps are after it.
+func (c *HLC) Observe(ts Timestamp) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if ts.Wall > c.last.Wall || (ts.Wall == c.last.Wall && ts.Logical > c.last.Logical) {
+		c.last = Timestamp{Wall: ts.Wall, Logical: ts.Logical, Node: c.node}
+	}
+}
+
+// ReplicaState is the state of the replicated cooldown, see Registry.Merge.
+// The expiration is grow-only: the latest one wins. The pause is the
+// last-writer-wins register of the start it belongs to: if expirations are
+// equal, the pause or resume with the latest timestamp wins, otherwise the
+// register of the state with the latest expiration wins and the other one is
+// dropped, so the pause of the older start never freezes the newer one.
+type ReplicaState struct {
+	// Expiration is the expiration date of the cooldown, zero if it was
+	// never started.
+	Expiration time.Time
+	// Duration is the duration the cooldown with the expiration was started
+	// with.
+	Duration time.Duration
+	// PausedDate is the date the cooldown was paused at, zero if it is not
+	// paused.
+	PausedDate time.Time
+	// PauseStamp is the timestamp of the last pause or resume.
+	PauseStamp Timestamp
+}
+
+// State returns the state of the cooldown at provided time.
+func (s ReplicaState) State(now time.Time) BasicState {
+	return basicStateAt(s.Expiration, s.PausedDate, now)
+}
+
+// Merge merges the states. Like Merge of BasicState, it is commutative,
+// associative and idempotent.
+func (s ReplicaState) Merge(other ReplicaState) ReplicaState {
+	switch {
+	case other.Expiration.After(s.Expiration):
+		return other
+	case other.Expiration.Before(s.Expiration):
+		return s
+	}
+	res := s
+	res.Duration = max(s.Duration, other.Duration)
+	if pauseWins(other, s) {
+		res.PausedDate, res.PauseStamp = other.PausedDate, other.PauseStamp
+	}
+	return res
+}
+
+// pauseWins returns true if the pause register of a wins over the one of b.
+func pauseWins(a, b ReplicaState) bool {
+	if c := a.PauseStamp.Compare(b.PauseStamp); c != 0 {
+		return c > 0
+	}
+	// Equal timestamps mean the same event, or no timestamps at all.
+	return a.PausedDate.After(b.PausedDate)
+}
+
+// Equal returns true if the states are equal.
+func (s ReplicaState) Equal(other ReplicaState) bool {
+	return s.Expiration.Equal(other.Expiration) && s.Duration == other.Duration &&
+		s.PausedDate.Equal(other.PausedDate) && s.PauseStamp == other.PauseStamp
+}
+
+func (cooldown *Valued[T]) stampPauseUnsafe() {
+	if cooldown.stamp != nil {
+		cooldown.pauseStamp = cooldown.stamp()
+	}
+}
+
+// ReplicaState returns the replicated state of the cooldown.
+func (cooldown *Valued[T]) ReplicaState() ReplicaState {
+	cooldown.mu.RLock()
+	defer cooldown.mu.RUnlock()
+	return cooldown.ReplicaStateUnsafe()
+}
+
+func (cooldown *Valued[T]) ReplicaStateUnsafe() ReplicaState {
+	s := ReplicaState{PauseStamp: cooldown.pauseStamp}
+	if cooldown.ActiveUnsafe() {
+		s.Expiration, s.Duration = cooldown.basic.expiration, cooldown.duration
+		s.PausedDate = cooldown.basic.pausedAt
+	}
+	return s
+}
+
+// Merge merges the state received from another replica into the cooldown.
+// Like Restore, it doesn't call handlers, but the cooldown expires as usual.
+// Returns true if the state of the cooldown was changed.
+func (cooldown *Valued[T]) Merge(remote ReplicaState) bool {
+	cooldown.mu.Lock()
+	defer cooldown.mu.Unlock()
+	return cooldown.MergeUnsafe(remote)
+}
+
+func (cooldown *Valued[T]) MergeUnsafe(remote ReplicaState) bool {
+	local := cooldown.ReplicaStateUnsafe()
+	merged := local.Merge(remote)
+	if merged.Equal(local) {
+		return false
+	}
+	cooldown.pauseStamp = merged.PauseStamp
+	if !merged.State(cooldown.basic.now()).Active {
+		// Remote state is already expired, only the pause register changed.
+		return false
+	}
+	if !cooldown.ActiveUnsafe() {
+		cooldown.gen++
+	}
+	if timer := cooldown.timer; timer != nil {
+		timer.Stop()
+		cooldown.timer = nil
+	}
+	cooldown.basic.expiration, cooldown.basic.pausedAt = merged.Expiration, merged.PausedDate
+	cooldown.duration = merged.Duration
+	if merged.PausedDate.IsZero() {
+		cooldown.scheduleUnsafe(cooldown.basic.RemainingUnsafe())
+	}
+	cooldown.syncOriginUnsafe()
+	return true
+}
+
+// ReplicaStates returns replicated states of all the cooldowns, e.g. to send
+// them to other replicas.
+func (reg *Registry[K, T]) ReplicaStates() map[K]ReplicaState {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+	states := make(map[K]ReplicaState, len(reg.cooldowns))
+	for key, cd := range reg.cooldowns {
+		states[key] = cd.ReplicaState()
+	}
+	return states
+}
+
+// Merge merges states received from another replica into the cooldowns of
+// the registry, creating them if needed. See ReplicaState for the semantics.
+// Timestamps of the states are observed by the clock of the registry, so the
+// following local pauses and resumes win over them. Returns count of the
+// changed cooldowns.
+func (reg *Registry[K, T]) Merge(states map[K]ReplicaState) int {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	changed := 0
+	for key, s := range states {
+		if reg.hlc != nil && !s.PauseStamp.IsZero() {
+			reg.hlc.Observe(s.PauseStamp)
+		}
+		cd, ok := reg.cooldowns[key]
+		if !ok {
+			cd = reg.newValued(key)
+			if !s.State(cd.basic.now()).Active {
+				continue
+			}
+			reg.cooldowns[key] = cd
+		}
+		if cd.Merge(s) {
+			changed++
+		}
+	}
+	return changed
+}
@@ -0,0 +1,175 @@
+package cooldown_test
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+	"github.com/k4ties/cooldown"
+)
+
+// Times are taken from the small set, so random states often tie.
+var replicaEpoch = time.Unix(1700000000, 0)
+
+func randTime(r *rand.Rand) time.Time {
+	if r.Intn(4) == 0 {
+		return time.Time{}
+	}
+	return replicaEpoch.Add(time.Duration(r.Intn(4)) * time.Second)
+}
+
+func randBasicState(r *rand.Rand) cooldown.BasicState {
+	s := cooldown.BasicState{Expiration: randTime(r), PausedDate: randTime(r)}
+	s.Active, s.Paused = r.Intn(2) == 0, r.Intn(2) == 0
+	return s
+}
+
+func randReplicaState(r *rand.Rand) cooldown.ReplicaState {
+	s := cooldown.ReplicaState{
+		Expiration: randTime(r),
+		Duration:   time.Duration(r.Intn(3)) * time.Second,
+		PausedDate: randTime(r),
+	}
+	if r.Intn(4) != 0 {
+		s.PauseStamp = cooldown.Timestamp{
+			Wall:    int64(r.Intn(3)),
+			Logical: uint32(r.Intn(2)),
+			Node:    []string{"a", "b"}[r.Intn(2)],
+		}
+	}
+	return s
+}
+
+func TestMergeProperties(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for range 10000 {
+		a, b, c := randBasicState(r), randBasicState(r), randBasicState(r)
+		assert.Equal(t, cooldown.Merge(a, b), cooldown.Merge(b, a))
+		assert.Equal(t, cooldown.Merge(a, a), a)
+		assert.Equal(t, cooldown.Merge(cooldown.Merge(a, b), c), cooldown.Merge(a, cooldown.Merge(b, c)))
+	}
+}
+
+func TestReplicaStateMergeProperties(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for range 10000 {
+		a, b, c := randReplicaState(r), randReplicaState(r), randReplicaState(r)
+		assert.Equal(t, a.Merge(b), b.Merge(a))
+		assert.Equal(t, a.Merge(a), a)
+		assert.Equal(t, a.Merge(b).Merge(c), a.Merge(b.Merge(c)))
+	}
+}
+
+func TestMerge(t *testing.T) {
+	now := time.Now()
+	running := cooldown.BasicState{Active: true, Expiration: now.Add(time.Minute)}
+	longer := cooldown.BasicState{Active: true, Expiration: now.Add(time.Hour)}
+	assert.Equal(t, cooldown.Merge(running, longer), longer)
+	assert.Equal(t, cooldown.Merge(running, cooldown.BasicState{}), running)
+
+	paused := running
+	paused.Paused, paused.PausedDate = true, now
+	assert.Equal(t, cooldown.Merge(running, paused), paused)
+}
+
+func TestHLC(t *testing.T) {
+	clock := newFakeClock()
+	a, b := cooldown.NewHLC("a", clock), cooldown.NewHLC("b", clock)
+
+	first := a.Now()
+	second := a.Now()
+	assert.Equal(t, first.Compare(second), -1)
+	assert.Equal(t, first.Node, "a")
+
+	// Timestamps received from the node with faster clock are observed, so
+	// the following events are ordered after them.
+	remote := cooldown.Timestamp{Wall: second.Wall + int64(time.Hour), Node: "c"}
+	b.Observe(remote)
+	assert.Equal(t, remote.Compare(b.Now()), -1)
+
+	clock.Advance(time.Second)
+	assert.Equal(t, second.Compare(a.Now()), -1)
+	assert.Equal(t, cooldown.Timestamp{}.IsZero(), true)
+}
+
+func TestRegistryMerge(t *testing.T) {
+	hlcA, hlcB := cooldown.NewHLC("a", nil), cooldown.NewHLC("b", nil)
+	a := cooldown.NewRegistry[string, struct{}](cooldown.RegistryOptionReplica[string, struct{}](hlcA))
+	b := cooldown.NewRegistry[string, struct{}](cooldown.RegistryOptionReplica[string, struct{}](hlcB))
+
+	// Partitioned replicas start the same key independently.
+	a.Get("key").Start(time.Minute, struct{}{})
+	b.Get("key").Start(time.Hour, struct{}{})
+	b.Get("other").Start(time.Minute, struct{}{})
+	b.Get("other").Pause(struct{}{})
+
+	assert.Equal(t, a.Merge(b.ReplicaStates()), 2)
+	assert.Equal(t, b.Merge(a.ReplicaStates()), 0)
+	assert.Equal(t, a.Get("key").Duration(), time.Hour)
+	assert.Equal(t, a.Get("other").Paused(), true)
+
+	// Resume after the pause was observed wins over it.
+	a.Get("other").Resume(struct{}{})
+	assert.Equal(t, b.Merge(a.ReplicaStates()), 1)
+	assert.Equal(t, b.Get("other").Paused(), false)
+	assert.Equal(t, a.Merge(b.ReplicaStates()), 0)
+
+	// Expired states don't create cooldowns.
+	expired := map[string]cooldown.ReplicaState{"expired": {Expiration: time.Now().Add(-time.Second)}}
+	assert.Equal(t, a.Merge(expired), 0)
+	assert.Equal(t, len(a.ReplicaStates()), 2)
+
+	// Merged cooldowns expire as usual.
+	states := map[string]cooldown.ReplicaState{"short": {
+		Expiration: time.Now().Add(time.Millisecond * 20),
+		Duration:   time.Millisecond * 20,
+	}}
+	assert.Equal(t, a.Merge(states), 1)
+	assert.Equal(t, a.Get("short").Active(), true)
+	time.Sleep(time.Millisecond * 50)
+	assert.Equal(t, a.Get("short").Active(), false)
+}
+
+func TestReplicaStateMergeStalePause(t *testing.T) {
+	now := time.Now()
+	// The pause was stamped for the older start, so it is dropped together
+	// with its expiration and doesn't freeze the newer start.
+	paused := cooldown.ReplicaState{
+		Expiration: now.Add(time.Minute),
+		Duration:   time.Minute,
+		PausedDate: now,
+		PauseStamp: cooldown.Timestamp{Wall: 2, Node: "a"},
+	}
+	restarted := cooldown.ReplicaState{
+		Expiration: now.Add(time.Hour),
+		Duration:   time.Hour,
+		PauseStamp: cooldown.Timestamp{Wall: 1, Node: "b"},
+	}
+	assert.Equal(t, paused.Merge(restarted), restarted)
+	assert.Equal(t, restarted.Merge(paused), restarted)
+
+	// Pause of the same start wins by its timestamp.
+	running := paused
+	running.PausedDate, running.PauseStamp = time.Time{}, cooldown.Timestamp{Wall: 1, Node: "b"}
+	assert.Equal(t, running.Merge(paused), paused)
+}
+
+func TestRegistryMergeClock(t *testing.T) {
+	clock := newFakeClock()
+	reg := cooldown.NewRegistry[string, struct{}](
+		cooldown.RegistryOptionValued[string, struct{}](cooldown.ValuedOptionClock[struct{}](clock)),
+	)
+
+	// Expiration is in the past of the system clock, but not of the clock of
+	// the registry.
+	states := map[string]cooldown.ReplicaState{"key": {
+		Expiration: clock.Now().Add(time.Minute),
+		Duration:   time.Minute,
+	}}
+	assert.Equal(t, reg.Merge(states), 1)
+	assert.Equal(t, reg.Get("key").Active(), true)
+
+	clock.Advance(time.Minute + time.Second)
+	assert.Equal(t, reg.Merge(map[string]cooldown.ReplicaState{"other": states["key"]}), 0)
+}
@@ -0,0 +1,234 @@
+package cooldown
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Scope is the bucket the command cooldown applies to.
+type Scope uint8
+
+const (
+	// ScopeGlobal cooldown is shared by everyone.
+	ScopeGlobal Scope = iota
+	// ScopeUser cooldown is separate for every user.
+	ScopeUser
+	// ScopeChannel cooldown is separate for every channel.
+	ScopeChannel
+	// ScopeGuild cooldown is separate for every guild.
+	ScopeGuild
+)
+
+// String ...
+func (s Scope) String() string {
+	switch s {
+	case ScopeGlobal:
+		return "global"
+	case ScopeUser:
+		return "user"
+	case ScopeChannel:
+		return "channel"
+	case ScopeGuild:
+		return "guild"
+	default:
+		return fmt.Sprintf("Scope(%d)", uint8(s))
+	}
+}
+
+// Invocation describes the use of the command. Zero channel or guild (e.g. in
+// direct messages) falls back to the user, so such uses are still limited.
+type Invocation[I comparable] struct {
+	Command              string
+	User, Channel, Guild I
+}
+
+// ID returns the ID the cooldown of provided scope is separated by.
+func (inv Invocation[I]) ID(scope Scope) I {
+	var zeroI I
+	switch scope {
+	case ScopeUser:
+		return inv.User
+	case ScopeChannel:
+		if inv.Channel != zeroI {
+			return inv.Channel
+		}
+		return inv.User
+	case ScopeGuild:
+		if inv.Guild != zeroI {
+			return inv.Guild
+		}
+		return inv.User
+	default:
+		return zeroI
+	}
+}
+
+// IDScope returns the scope the ID of provided scope belongs to. It is
+// ScopeUser if the ID falls back to the user.
+func (inv Invocation[I]) IDScope(scope Scope) Scope {
+	var zeroI I
+	switch {
+	case scope == ScopeChannel && inv.Channel == zeroI,
+		scope == ScopeGuild && inv.Guild == zeroI:
+		return ScopeUser
+	default:
+		return scope
+	}
+}
+
+// ScopeKey is the composite key of the scoped cooldown in the registry.
+type ScopeKey[I comparable] struct {
+	Command string
+	Scope   Scope
+	// IDScope is the scope the ID belongs to, see Invocation.IDScope. It
+	// keeps the keys of the users falling back from the channel or guild
+	// apart from the keys of the channels or guilds with the same ID.
+	IDScope Scope
+	ID      I
+}
+
+// ScopeRule is the cooldown of the command in one scope.
+type ScopeRule struct {
+	Scope    Scope
+	Duration time.Duration
+}
+
+// ScopeError is returned when the use is blocked by the cooldown of one of the
+// scopes of the command. It unwraps to *ErrOnCooldown.
+type ScopeError[I comparable] struct {
+	Key       ScopeKey[I]
+	Remaining time.Duration
+}
+
+func (err *ScopeError[I]) Error() string {
+	return fmt.Sprintf("%s on %s cooldown, %s remaining", err.Key.Command, err.Key.Scope, err.Remaining)
+}
+
+func (err *ScopeError[I]) Unwrap() error {
+	return &ErrOnCooldown{Remaining: err.Remaining}
+}
+
+// Scoped manages command cooldowns that apply to several scopes at once, like
+// the user and the guild. The use passes only if cooldowns of all the scopes
+// of the command are inactive, and then all of them are started.
+type Scoped[I comparable, T any] struct {
+	mu sync.RWMutex
+
+	reg    *Registry[ScopeKey[I], T]
+	rules  map[string][]ScopeRule
+	bypass []func(inv Invocation[I]) bool
+}
+
+// NewScoped creates new Scoped cooldown manager.
+func NewScoped[I comparable, T any](opts ...ScopedOption[I, T]) *Scoped[I, T] {
+	s := &Scoped[I, T]{rules: make(map[string][]ScopeRule)}
+	for _, opt := range opts {
+		if opt == nil {
+			continue
+		}
+		opt(s)
+	}
+	if s.reg == nil {
+		s.reg = NewRegistry[ScopeKey[I], T]()
+	}
+	return s
+}
+
+// Register sets cooldowns of the command. Rules replace the previous ones.
+func (s *Scoped[I, T]) Register(command string, rules ...ScopeRule) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rules[command] = append([]ScopeRule(nil), rules...)
+}
+
+// Use checks cooldowns of all the scopes of the command and starts them if
+// none is active. If some are active, *ScopeError of the one with the longest
+// remaining duration is returned. Uses that match any bypass predicate always
+// pass and don't start cooldowns. Errors of the registry constraints are
+// returned as is.
+func (s *Scoped[I, T]) Use(inv Invocation[I], val T) error {
+	s.mu.RLock()
+	rules, bypass := s.rules[inv.Command], s.bypass
+	s.mu.RUnlock()
+	for _, fn := range bypass {
+		if fn(inv) {
+			return nil
+		}
+	}
+
+	s.reg.mu.Lock()
+	defer s.reg.mu.Unlock()
+
+	var blocked *ScopeError[I]
+	for _, rule := range rules {
+		key := s.key(inv, rule.Scope)
+		cd, ok := s.reg.LookupUnsafe(key)
+		if !ok {
+			continue
+		}
+		if remaining, active := cd.activeRemaining(); active {
+			s.reg.violateUnsafe(key, remaining)
+			if blocked == nil || remaining > blocked.Remaining {
+				blocked = &ScopeError[I]{Key: key, Remaining: remaining}
+			}
+		}
+	}
+	if blocked != nil {
+		return blocked
+	}
+	for _, rule := range rules {
+		if err := s.reg.CheckUnsafe(s.key(inv, rule.Scope), val); err != nil {
+			return err
+		}
+	}
+	for i, rule := range rules {
+		cd := s.reg.GetUnsafe(s.key(inv, rule.Scope))
+		if err := cd.start(rule.Duration, val); err != nil {
+			// Roll back the scopes that were already started.
+			for _, started := range rules[:i] {
+				s.reg.GetUnsafe(s.key(inv, started.Scope)).Stop(val)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// Remaining returns the longest remaining duration of the cooldowns of the
+// command, and the scope it belongs to.
+func (s *Scoped[I, T]) Remaining(inv Invocation[I]) (time.Duration, Scope) {
+	s.mu.RLock()
+	rules := s.rules[inv.Command]
+	s.mu.RUnlock()
+
+	var (
+		longest time.Duration
+		scope   Scope
+	)
+	for _, rule := range rules {
+		if remaining := s.reg.Remaining(s.key(inv, rule.Scope)); remaining > longest {
+			longest, scope = remaining, rule.Scope
+		}
+	}
+	return longest, scope
+}
+
+// Reset stops cooldowns of all the scopes of the command.
+func (s *Scoped[I, T]) Reset(inv Invocation[I], val T) {
+	s.mu.RLock()
+	rules := s.rules[inv.Command]
+	s.mu.RUnlock()
+	for _, rule := range rules {
+		s.reg.Stop(s.key(inv, rule.Scope), val)
+	}
+}
+
+// Registry returns the registry the scoped cooldowns are stored in.
+func (s *Scoped[I, T]) Registry() *Registry[ScopeKey[I], T] {
+	return s.reg
+}
+
+func (s *Scoped[I, T]) key(inv Invocation[I], scope Scope) ScopeKey[I] {
+	return ScopeKey[I]{Command: inv.Command, Scope: scope, IDScope: inv.IDScope(scope), ID: inv.ID(scope)}
+}
@@ -0,0 +1,57 @@
+package cooldown_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+	"github.com/k4ties/cooldown"
+)
+
+func TestScoped(t *testing.T) {
+	const admin = 1
+	s := cooldown.NewScoped(cooldown.ScopedOptionBypass[int, struct{}](func(inv cooldown.Invocation[int]) bool {
+		return inv.User == admin
+	}))
+	s.Register("ping",
+		cooldown.ScopeRule{Scope: cooldown.ScopeUser, Duration: time.Second},
+		cooldown.ScopeRule{Scope: cooldown.ScopeGuild, Duration: time.Minute},
+	)
+
+	inv := cooldown.Invocation[int]{Command: "ping", User: 2, Channel: 10, Guild: 100}
+	assert.Equal(t, s.Use(inv, struct{}{}), nil)
+
+	// Another user in the same guild is blocked by the guild scope
+	other := inv
+	other.User = 3
+	var scopeErr *cooldown.ScopeError[int]
+	assert.Equal(t, errors.As(s.Use(other, struct{}{}), &scopeErr), true)
+	assert.Equal(t, scopeErr.Key.Scope, cooldown.ScopeGuild)
+	assert.Equal(t, scopeErr.Key.ID, 100)
+
+	var onCooldown *cooldown.ErrOnCooldown
+	assert.Equal(t, errors.As(s.Use(other, struct{}{}), &onCooldown), true)
+
+	remaining, scope := s.Remaining(inv)
+	assert.Equal(t, scope, cooldown.ScopeGuild)
+	assert.Equal(t, remaining > time.Second, true)
+
+	// Blocked use didn't start the user scope of the other user
+	assert.Equal(t, s.Registry().Active(cooldown.ScopeKey[int]{Command: "ping", Scope: cooldown.ScopeUser, IDScope: cooldown.ScopeUser, ID: 3}), false)
+
+	// Direct messages fall back to the user
+	dm := cooldown.Invocation[int]{Command: "ping", User: 4}
+	assert.Equal(t, s.Use(dm, struct{}{}), nil)
+	assert.NotEqual(t, s.Use(dm, struct{}{}), nil)
+	// ...but don't share the cooldown with the guild that has the same ID.
+	guild := cooldown.Invocation[int]{Command: "ping", User: 5, Channel: 11, Guild: 4}
+	assert.Equal(t, s.Use(guild, struct{}{}), nil)
+
+	adminInv := inv
+	adminInv.User = admin
+	assert.Equal(t, s.Use(adminInv, struct{}{}), nil)
+
+	s.Reset(inv, struct{}{})
+	assert.Equal(t, s.Use(other, struct{}{}), nil)
+}
@@ -0,0 +1,293 @@
+package cooldown
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/k4ties/cooldown/internal/event"
+)
+
+// Step represents single step of the Sequence.
+type Step[T any] struct {
+	// Name is the unique name of the step.
+	Name string
+	// Duration is how long the step lasts. It must be positive.
+	Duration time.Duration
+	// Value is the value the step cooldown is started with.
+	Value T
+	// OnExpire is the name of the step started when this step expires. If it
+	// is empty, sequence ends.
+	OnExpire string
+	// OnCancel is the name of the step started when this step is cancelled
+	// via Sequence.CancelStep. If it is empty, sequence ends.
+	OnCancel string
+	// Handler handles events of the step cooldown. It may be nil.
+	Handler ValuedHandler[T]
+}
+
+// Sequence is chain of cooldowns, where expiration or cancellation of one
+// step starts another one. Steps run on the single underlying Valued, so only
+// one step of the sequence can be active at once.
+type Sequence[T any] struct {
+	// All the state is controlled by the lock of the underlying Valued.
+	valued *Valued[T]
+
+	steps   map[string]Step[T]
+	first   string
+	current string // empty if inactive
+
+	handler atomic.Pointer[SequenceHandler[T]]
+}
+
+// NewSequence creates new Sequence from provided steps. The first step is the
+// one started by Start. Returns error if names of the steps are not unique,
+// any step has non-positive duration or refers to unknown step.
+func NewSequence[T any](steps []Step[T], opts ...SequenceOption[T]) (*Sequence[T], error) {
+	if len(steps) == 0 {
+		return nil, errors.New("sequence must have at least one step")
+	}
+	seq := &Sequence[T]{steps: make(map[string]Step[T], len(steps)), first: steps[0].Name}
+	for _, step := range steps {
+		if step.Name == "" {
+			return nil, errors.New("step name must not be empty")
+		}
+		if step.Duration <= 0 {
+			return nil, fmt.Errorf("step %q must have positive duration", step.Name)
+		}
+		if _, ok := seq.steps[step.Name]; ok {
+			return nil, fmt.Errorf("duplicate step %q", step.Name)
+		}
+		if step.Handler == nil {
+			step.Handler = NopValuedHandler[T]{}
+		}
+		seq.steps[step.Name] = step
+	}
+	for _, step := range steps {
+		for _, next := range []string{step.OnExpire, step.OnCancel} {
+			if _, ok := seq.steps[next]; next != "" && !ok {
+				return nil, fmt.Errorf("step %q refers to unknown step %q", step.Name, next)
+			}
+		}
+	}
+	seq.valued = NewValued[T](ValuedOptionHandler[T](sequenceHandler[T]{seq: seq}))
+	for _, opt := range opts {
+		if opt == nil {
+			continue
+		}
+		opt(seq)
+	}
+	if seq.handler.Load() == nil {
+		h := SequenceHandler[T](NopSequenceHandler[T]{})
+		seq.handler.Store(&h)
+	}
+	return seq, nil
+}
+
+// Start starts the sequence from the first step. If sequence is already
+// active, it is cancelled first.
+func (seq *Sequence[T]) Start() bool {
+	return seq.StartAt(seq.first)
+}
+
+// StartAt starts the sequence from the step with provided name.
+func (seq *Sequence[T]) StartAt(name string) bool {
+	seq.valued.mu.Lock()
+	defer seq.valued.mu.Unlock()
+	return seq.StartAtUnsafe(name)
+}
+
+func (seq *Sequence[T]) StartAtUnsafe(name string) bool {
+	if _, ok := seq.steps[name]; !ok {
+		return false
+	}
+	seq.CancelUnsafe()
+	return seq.enterUnsafe("", name, nil)
+}
+
+// CancelStep cancels the current step and starts the step it branches to on
+// cancellation. Returns false if sequence is inactive.
+func (seq *Sequence[T]) CancelStep() bool {
+	seq.valued.mu.Lock()
+	defer seq.valued.mu.Unlock()
+	return seq.CancelStepUnsafe()
+}
+
+func (seq *Sequence[T]) CancelStepUnsafe() bool {
+	step, ok := seq.steps[seq.current]
+	if !ok {
+		return false
+	}
+	seq.valued.StopUnsafe(step.Value)
+	seq.enterUnsafe(step.Name, step.OnCancel, ErrStopCauseCancelled)
+	return true
+}
+
+// Cancel cancels the whole sequence without following any branches.
+func (seq *Sequence[T]) Cancel() {
+	seq.valued.mu.Lock()
+	defer seq.valued.mu.Unlock()
+	seq.CancelUnsafe()
+}
+
+func (seq *Sequence[T]) CancelUnsafe() {
+	step, ok := seq.steps[seq.current]
+	if !ok {
+		return
+	}
+	seq.valued.StopUnsafe(step.Value)
+	seq.current = ""
+	seq.Handler().HandleStop(seq, ErrStopCauseCancelled)
+}
+
+// enterUnsafe starts step with provided name. If name is empty or step was
+// cancelled by handler, sequence ends with provided cause.
+func (seq *Sequence[T]) enterUnsafe(from, to string, cause StopCause) bool {
+	seq.current = ""
+	if to != "" {
+		ctx := event.C(seq)
+		if seq.Handler().HandleStep(ctx, from, to, cause); !ctx.Cancelled() {
+			step := seq.steps[to]
+			seq.current = to
+			if seq.valued.StartUnsafe(step.Duration, step.Value) {
+				return true
+			}
+			seq.current = ""
+		}
+	}
+	if from != "" {
+		seq.Handler().HandleStop(seq, cause)
+	}
+	return false
+}
+
+// Ready returns true if step with provided name isn't currently active.
+func (seq *Sequence[T]) Ready(name string) bool {
+	seq.valued.mu.RLock()
+	defer seq.valued.mu.RUnlock()
+	return seq.ReadyUnsafe(name)
+}
+
+func (seq *Sequence[T]) ReadyUnsafe(name string) bool {
+	return seq.current != name || !seq.valued.ActiveUnsafe()
+}
+
+// Remaining returns duration until step with provided name expires. It is
+// zero if the step is not active.
+func (seq *Sequence[T]) Remaining(name string) time.Duration {
+	seq.valued.mu.RLock()
+	defer seq.valued.mu.RUnlock()
+	return seq.RemainingUnsafe(name)
+}
+
+func (seq *Sequence[T]) RemainingUnsafe(name string) time.Duration {
+	if seq.current != name {
+		return 0
+	}
+	return seq.valued.RemainingUnsafe()
+}
+
+// Current returns name of the current step. Returns false if sequence is
+// inactive.
+func (seq *Sequence[T]) Current() (string, bool) {
+	seq.valued.mu.RLock()
+	defer seq.valued.mu.RUnlock()
+	return seq.CurrentUnsafe()
+}
+
+func (seq *Sequence[T]) CurrentUnsafe() (string, bool) {
+	return seq.current, seq.current != ""
+}
+
+// Active ...
+func (seq *Sequence[T]) Active() bool {
+	seq.valued.mu.RLock()
+	defer seq.valued.mu.RUnlock()
+	return seq.ActiveUnsafe()
+}
+
+func (seq *Sequence[T]) ActiveUnsafe() bool {
+	return seq.current != ""
+}
+
+// Pause pauses the current step.
+func (seq *Sequence[T]) Pause() bool {
+	seq.valued.mu.Lock()
+	defer seq.valued.mu.Unlock()
+	return seq.PauseUnsafe()
+}
+
+func (seq *Sequence[T]) PauseUnsafe() bool {
+	return seq.valued.PauseUnsafe(seq.steps[seq.current].Value)
+}
+
+// Resume resumes the current step.
+func (seq *Sequence[T]) Resume() bool {
+	seq.valued.mu.Lock()
+	defer seq.valued.mu.Unlock()
+	return seq.ResumeUnsafe()
+}
+
+func (seq *Sequence[T]) ResumeUnsafe() bool {
+	return seq.valued.ResumeUnsafe(seq.steps[seq.current].Value)
+}
+
+// Handler ...
+func (seq *Sequence[T]) Handler() SequenceHandler[T] {
+	return *seq.handler.Load()
+}
+
+// Handle ...
+func (seq *Sequence[T]) Handle(handler SequenceHandler[T]) {
+	if handler == nil {
+		handler = NopSequenceHandler[T]{}
+	}
+	seq.handler.Store(&handler)
+}
+
+// Valued returns the underlying Valued cooldown. Its handler is used by the
+// Sequence, so it must not be changed.
+func (seq *Sequence[T]) Valued() *Valued[T] {
+	return seq.valued
+}
+
+// sequenceHandler forwards events of the underlying Valued to the handler of
+// the current step, and follows branches when step expires.
+type sequenceHandler[T any] struct {
+	seq *Sequence[T]
+}
+
+func (h sequenceHandler[T]) step() ValuedHandler[T] {
+	if step, ok := h.seq.steps[h.seq.current]; ok {
+		return step.Handler
+	}
+	return NopValuedHandler[T]{}
+}
+
+func (h sequenceHandler[T]) HandleStart(ctx *ValuedContext[T], dur time.Duration, val T) {
+	h.step().HandleStart(ctx, dur, val)
+}
+
+func (h sequenceHandler[T]) HandleRenew(ctx *ValuedContext[T], dur time.Duration, val T) {
+	h.step().HandleRenew(ctx, dur, val)
+}
+
+func (h sequenceHandler[T]) HandleStop(cd *Valued[T], cause StopCause, val T) {
+	h.step().HandleStop(cd, cause, val)
+	if cause != ErrStopCauseExpired {
+		// Cancellation is handled by the Sequence itself.
+		return
+	}
+	if step, ok := h.seq.steps[h.seq.current]; ok {
+		h.seq.enterUnsafe(step.Name, step.OnExpire, cause)
+	}
+}
+
+func (h sequenceHandler[T]) HandlePause(ctx *ValuedContext[T], val T) {
+	h.step().HandlePause(ctx, val)
+}
+
+func (h sequenceHandler[T]) HandleResume(ctx *ValuedContext[T], val T) {
+	h.step().HandleResume(ctx, val)
+}
@@ -0,0 +1,112 @@
+package cooldown_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+	"github.com/k4ties/cooldown"
+)
+
+type stepRecorder struct {
+	cooldown.NopSequenceHandler[string]
+
+	mu    sync.Mutex
+	steps []string
+	stops []cooldown.StopCause
+}
+
+func (h *stepRecorder) HandleStep(_ *cooldown.SequenceContext[string], _, to string, _ cooldown.StopCause) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.steps = append(h.steps, to)
+}
+
+func (h *stepRecorder) HandleStop(_ *cooldown.Sequence[string], cause cooldown.StopCause) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.stops = append(h.stops, cause)
+}
+
+func (h *stepRecorder) result() ([]string, []cooldown.StopCause) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]string(nil), h.steps...), append([]cooldown.StopCause(nil), h.stops...)
+}
+
+func comboSteps() []cooldown.Step[string] {
+	return []cooldown.Step[string]{
+		{Name: "jab", Duration: time.Millisecond * 10, Value: "jab", OnExpire: "cross", OnCancel: "recover"},
+		{Name: "cross", Duration: time.Millisecond * 10, Value: "cross"},
+		{Name: "recover", Duration: time.Second, Value: "recover"},
+	}
+}
+
+func TestSequence(t *testing.T) {
+	t.Run("invalid steps", func(t *testing.T) {
+		_, err := cooldown.NewSequence([]cooldown.Step[string]{{Name: "a", Duration: time.Second, OnExpire: "b"}})
+		assert.NotEqual(t, err, nil)
+		_, err = cooldown.NewSequence([]cooldown.Step[string]{{Name: "a", Duration: time.Second}, {Name: "a", Duration: time.Second}})
+		assert.NotEqual(t, err, nil)
+		// Step with zero duration would silently end the sequence.
+		_, err = cooldown.NewSequence([]cooldown.Step[string]{{Name: "a"}})
+		assert.NotEqual(t, err, nil)
+	})
+	t.Run("expire branch", func(t *testing.T) {
+		h := new(stepRecorder)
+		seq, err := cooldown.NewSequence(comboSteps(), cooldown.SequenceOptionHandler[string](h))
+		assert.Equal(t, err, nil)
+
+		assert.Equal(t, seq.Start(), true)
+		assert.Equal(t, seq.Ready("jab"), false)
+		assert.Equal(t, seq.Ready("cross"), true)
+
+		<-time.After(time.Millisecond * 50)
+		assert.Equal(t, seq.Active(), false)
+		steps, stops := h.result()
+		assert.Equal(t, steps, []string{"jab", "cross"})
+		assert.Equal(t, stops, []cooldown.StopCause{cooldown.ErrStopCauseExpired})
+	})
+	t.Run("cancel branch", func(t *testing.T) {
+		h := new(stepRecorder)
+		seq, _ := cooldown.NewSequence(comboSteps(), cooldown.SequenceOptionHandler[string](h))
+
+		assert.Equal(t, seq.Start(), true)
+		assert.Equal(t, seq.CancelStep(), true)
+		current, _ := seq.Current()
+		assert.Equal(t, current, "recover")
+
+		seq.Cancel()
+		assert.Equal(t, seq.Active(), false)
+		assert.Equal(t, seq.Ready("recover"), true)
+		steps, stops := h.result()
+		assert.Equal(t, steps, []string{"jab", "recover"})
+		assert.Equal(t, stops, []cooldown.StopCause{cooldown.ErrStopCauseCancelled})
+	})
+}
+
+// stepStopRecorder records values the steps expired with.
+type stepStopRecorder struct {
+	cooldown.NopValuedHandler[string]
+	expired chan string
+}
+
+func (h stepStopRecorder) HandleStop(_ *cooldown.Valued[string], cause cooldown.StopCause, val string) {
+	if cause == cooldown.ErrStopCauseExpired {
+		h.expired <- val
+	}
+}
+
+func TestSequenceStepStop(t *testing.T) {
+	h := stepStopRecorder{expired: make(chan string, 2)}
+	steps := comboSteps()
+	steps[0].Handler, steps[1].Handler = h, h
+	seq, err := cooldown.NewSequence(steps)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, seq.Start(), true)
+
+	// Step handlers receive the value of the expired step.
+	assert.Equal(t, <-h.expired, "jab")
+	assert.Equal(t, <-h.expired, "cross")
+}
@@ -0,0 +1,198 @@
+package cooldown
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// Restore sets state of the cooldown, e.g. loaded from the database. Active
+// field of the state is ignored, it is computed from the dates.
+func (cooldown *Basic) Restore(state BasicState) {
+	cooldown.L.Lock()
+	defer cooldown.L.Unlock()
+	cooldown.RestoreUnsafe(state)
+}
+
+func (cooldown *Basic) RestoreUnsafe(state BasicState) {
+	cooldown.expiration, cooldown.pausedAt = state.Expiration, time.Time{}
+	if state.Paused {
+		cooldown.pausedAt = state.PausedDate
+	}
+}
+
+// Value implements driver.Valuer, so the state can be stored in the database
+// column as JSON.
+func (state BasicState) Value() (driver.Value, error) {
+	return jsonValue(state)
+}
+
+// Scan implements sql.Scanner, so the state can be read from the database
+// column stored by Value.
+func (state *BasicState) Scan(src any) error {
+	return scanJSON(src, state)
+}
+
+// ValuedSnapshot is the state of the Valued cooldown, e.g. to persist it.
+// State of repeating cooldowns and queued uses are not included.
+type ValuedSnapshot[T any] struct {
+	BasicState
+	// Duration is the duration cooldown was started with.
+	Duration time.Duration
+	// Val is the value cooldown was started with.
+	Val T
+	// Taken is the time the snapshot was taken at.
+	Taken time.Time
+}
+
+// Snapshot returns the current state of the cooldown.
+func (cooldown *Valued[T]) Snapshot() ValuedSnapshot[T] {
+	cooldown.mu.RLock()
+	defer cooldown.mu.RUnlock()
+	return cooldown.SnapshotUnsafe()
+}
+
+func (cooldown *Valued[T]) SnapshotUnsafe() ValuedSnapshot[T] {
+	s := ValuedSnapshot[T]{BasicState: cooldown.basic.StateUnsafe(), Taken: cooldown.basic.now()}
+	if s.Active {
+		s.Duration, s.Val = cooldown.duration, cooldown.val
+	}
+	return s
+}
+
+// Restore stops the cooldown and restores its state from the snapshot.
+// Handlers are not called, neither for the previous state, nor for the
+// restored one, since the cooldown was already started before. Returns false
+// if the snapshot is not active anymore, so nothing was restored. If the
+// snapshot was active, but has expired since it was taken, e.g. while the
+// process was down, HandleStop is called with ErrStopCauseExpiredOffline and
+// the value of the snapshot.
+func (cooldown *Valued[T]) Restore(s ValuedSnapshot[T]) bool {
+	cooldown.mu.Lock()
+	defer cooldown.mu.Unlock()
+	return cooldown.RestoreUnsafe(s)
+}
+
+func (cooldown *Valued[T]) RestoreUnsafe(s ValuedSnapshot[T]) bool {
+	cooldown.resetUnsafe()
+	cooldown.flushQueueUnsafe()
+	expiration, pausedAt := cooldown.restoredDates(s)
+	state := basicStateAt(expiration, pausedAt, cooldown.basic.now())
+	if !state.Active {
+		if s.Active {
+			cooldown.Handler().HandleStop(cooldown, ErrStopCauseExpiredOffline, s.Val)
+		}
+		return false
+	}
+	cooldown.basic.RestoreUnsafe(state)
+	cooldown.duration, cooldown.val = s.Duration, s.Val
+	if !state.Paused {
+		cooldown.scheduleUnsafe(cooldown.basic.RemainingUnsafe())
+	}
+	cooldown.gen++
+	return true
+}
+
+// restoredDates returns expiration and pause dates the snapshot is restored
+// with.
+func (cooldown *Valued[T]) restoredDates(s ValuedSnapshot[T]) (expiration, pausedAt time.Time) {
+	if s.Paused {
+		return s.Expiration, s.PausedDate
+	}
+	if cooldown.online && !s.Taken.IsZero() {
+		// The time the process was down is not counted.
+		return s.Expiration.Add(cooldown.basic.now().Sub(s.Taken)), time.Time{}
+	}
+	return s.Expiration, time.Time{}
+}
+
+// Snapshot returns snapshots of the active cooldowns.
+func (reg *Registry[K, T]) Snapshot() map[K]ValuedSnapshot[T] {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+	snapshots := make(map[K]ValuedSnapshot[T], len(reg.cooldowns))
+	for key, cd := range reg.cooldowns {
+		if s := cd.Snapshot(); s.Active {
+			snapshots[key] = s
+		}
+	}
+	return snapshots
+}
+
+// Restore restores cooldowns from the snapshots, see Valued.Restore.
+// Snapshots are restored in order of their expirations, so cooldowns expired
+// while the process was down are reported in the order they expired. Such
+// cooldowns are not added to the registry. Returns count of the restored
+// cooldowns.
+func (reg *Registry[K, T]) Restore(snapshots map[K]ValuedSnapshot[T]) int {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+
+	type restore struct {
+		key        K
+		cd         *Valued[T]
+		expiration time.Time
+	}
+	restores := make([]restore, 0, len(snapshots))
+	for key, s := range snapshots {
+		if !s.Active {
+			continue
+		}
+		cd, ok := reg.cooldowns[key]
+		if !ok {
+			cd = reg.newValued(key)
+		}
+		expiration, _ := cd.restoredDates(s)
+		restores = append(restores, restore{key: key, cd: cd, expiration: expiration})
+	}
+	slices.SortFunc(restores, func(a, b restore) int {
+		return a.expiration.Compare(b.expiration)
+	})
+
+	restored := 0
+	for _, r := range restores {
+		if r.cd.Restore(snapshots[r.key]) {
+			reg.cooldowns[r.key] = r.cd
+			restored++
+		}
+	}
+	return restored
+}
+
+// Value implements driver.Valuer, so the snapshot can be stored in the
+// database column as JSON. Value of the cooldown must be encodable by
+// encoding/json.
+func (s ValuedSnapshot[T]) Value() (driver.Value, error) {
+	return jsonValue(s)
+}
+
+// Scan implements sql.Scanner, so the snapshot can be read from the database
+// column stored by Value.
+func (s *ValuedSnapshot[T]) Scan(src any) error {
+	return scanJSON(src, s)
+}
+
+func jsonValue(v any) (driver.Value, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+func scanJSON[V any](src any, v *V) error {
+	var zeroV V
+	switch src := src.(type) {
+	case nil:
+		*v = zeroV
+		return nil
+	case []byte:
+		return json.Unmarshal(src, v)
+	case string:
+		return json.Unmarshal([]byte(src), v)
+	default:
+		return fmt.Errorf("cannot scan %T into %T", src, v)
+	}
+}
@@ -0,0 +1,164 @@
+package cooldown_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+	"github.com/k4ties/cooldown"
+)
+
+func TestBasicStateSQL(t *testing.T) {
+	basic := new(cooldown.Basic)
+	basic.Set(time.Minute)
+	basic.Pause()
+
+	v, err := basic.State().Value()
+	assert.Equal(t, err, nil)
+	var state cooldown.BasicState
+	assert.Equal(t, state.Scan([]byte(v.(string))), nil)
+	assert.Equal(t, state.Paused, true)
+
+	restored := new(cooldown.Basic)
+	restored.Restore(state)
+	assert.Equal(t, restored.Paused(), true)
+	assert.Equal(t, restored.Remaining().Round(time.Millisecond), basic.Remaining().Round(time.Millisecond))
+
+	assert.Equal(t, state.Scan(nil), nil)
+	assert.Equal(t, state, cooldown.BasicState{})
+	assert.NotEqual(t, state.Scan(42), nil)
+}
+
+func TestValuedSnapshot(t *testing.T) {
+	cd := cooldown.NewValued[string]()
+	cd.Start(time.Minute, "value")
+	cd.Pause("")
+
+	v, err := cd.Snapshot().Value()
+	assert.Equal(t, err, nil)
+	var s cooldown.ValuedSnapshot[string]
+	assert.Equal(t, s.Scan(v), nil)
+	assert.Equal(t, s.Val, "value")
+	assert.Equal(t, s.Duration, time.Minute)
+
+	restored := cooldown.NewValued[string]()
+	assert.Equal(t, restored.Restore(s), true)
+	assert.Equal(t, restored.Paused(), true)
+	assert.Equal(t, restored.Remaining().Round(time.Millisecond), cd.Remaining().Round(time.Millisecond))
+	assert.Equal(t, restored.Resume(""), true)
+	assert.Equal(t, restored.Snapshot().Val, "value")
+
+	// Expired snapshot is not restored
+	s = cooldown.ValuedSnapshot[string]{BasicState: cooldown.BasicState{Expiration: time.Now().Add(-time.Second)}}
+	assert.Equal(t, restored.Restore(s), false)
+	assert.Equal(t, restored.Active(), false)
+
+	// Restored cooldown expires with the timer
+	s = cooldown.ValuedSnapshot[string]{BasicState: cooldown.BasicState{Expiration: time.Now().Add(time.Millisecond * 20)}}
+	assert.Equal(t, restored.Restore(s), true)
+	time.Sleep(time.Millisecond * 50)
+	assert.Equal(t, restored.Active(), false)
+}
+
+// stopRecorder records values of the stops with ErrStopCauseExpiredOffline.
+type stopRecorder struct {
+	cooldown.NopValuedHandler[string]
+	offline *[]string
+}
+
+func (h stopRecorder) HandleStop(_ *cooldown.Valued[string], cause cooldown.StopCause, val string) {
+	if cause == cooldown.ErrStopCauseExpiredOffline {
+		*h.offline = append(*h.offline, val)
+	}
+}
+
+func TestRegistryRestore(t *testing.T) {
+	var offline []string
+	reg := cooldown.NewRegistry[string, string](cooldown.RegistryOptionValued[string, string](
+		cooldown.ValuedOptionHandler[string](stopRecorder{offline: &offline}),
+	))
+	now := time.Now()
+	expired := func(ago time.Duration, val string) cooldown.ValuedSnapshot[string] {
+		return cooldown.ValuedSnapshot[string]{
+			BasicState: cooldown.BasicState{Active: true, Expiration: now.Add(-ago)},
+			Duration:   time.Hour,
+			Val:        val,
+			Taken:      now.Add(-time.Hour),
+		}
+	}
+	snapshots := map[string]cooldown.ValuedSnapshot[string]{
+		"second": expired(time.Minute, "b"),
+		"first":  expired(time.Minute*2, "a"),
+		"third":  expired(time.Second, "c"),
+		"active": {BasicState: cooldown.BasicState{Active: true, Expiration: now.Add(time.Minute)}, Val: "d"},
+		"never":  {Val: "e"},
+	}
+	assert.Equal(t, reg.Restore(snapshots), 1)
+	assert.Equal(t, offline, []string{"a", "b", "c"})
+	// Expired cooldowns are not added to the registry.
+	assert.Equal(t, reg.Len(), 1)
+	assert.Equal(t, reg.Get("active").Active(), true)
+	assert.Equal(t, len(reg.Snapshot()), 1)
+	assert.Equal(t, reg.Snapshot()["active"].Val, "d")
+
+	// Snapshots are ordered by expirations shifted by the online time.
+	offline = nil
+	reg = cooldown.NewRegistry[string, string](cooldown.RegistryOptionValued[string, string](
+		cooldown.ValuedOptionHandler[string](stopRecorder{offline: &offline}),
+		cooldown.ValuedOptionOnlineTime[string](),
+	))
+	shifted := expired(time.Minute*30, "b")
+	shifted.Taken = now.Add(-time.Minute * 10)
+	unshifted := expired(time.Minute*25, "a")
+	unshifted.Taken = time.Time{}
+	assert.Equal(t, reg.Restore(map[string]cooldown.ValuedSnapshot[string]{"b": shifted, "a": unshifted}), 0)
+	assert.Equal(t, offline, []string{"a", "b"})
+	assert.Equal(t, reg.Len(), 0)
+}
+
+// restoreHandler records the handler calls.
+type restoreHandler struct {
+	cooldown.NopValuedHandler[string]
+	calls []string
+}
+
+func (h *restoreHandler) HandleStop(_ *cooldown.Valued[string], cause cooldown.StopCause, val string) {
+	h.calls = append(h.calls, cause.Error()+" "+val)
+}
+
+func (h *restoreHandler) HandleResume(_ *cooldown.ValuedContext[string], val string) {
+	h.calls = append(h.calls, "resume "+val)
+}
+
+func TestValuedRestoreHandlers(t *testing.T) {
+	h := new(restoreHandler)
+	cd := cooldown.NewValued[string](cooldown.ValuedOptionHandler[string](h))
+	cd.Start(time.Minute, "value")
+	cd.Pause("value")
+	h.calls = nil
+
+	// Previous state is dropped without handlers.
+	s := cooldown.ValuedSnapshot[string]{BasicState: cooldown.BasicState{Active: true, Expiration: time.Now().Add(time.Minute)}}
+	assert.Equal(t, cd.Restore(s), true)
+	assert.Equal(t, cd.Restore(cooldown.ValuedSnapshot[string]{}), false)
+	assert.Equal(t, cd.Active(), false)
+	assert.Equal(t, len(h.calls), 0)
+}
+
+func TestValuedOnlineTime(t *testing.T) {
+	cd := cooldown.NewValued[string](cooldown.ValuedOptionOnlineTime[string]())
+	cd.Start(time.Minute, "value")
+	s := cd.Snapshot()
+
+	// Snapshot was taken an hour ago, but the process was down since then.
+	s.Expiration, s.Taken = s.Expiration.Add(-time.Hour), s.Taken.Add(-time.Hour)
+	restored := cooldown.NewValued[string](cooldown.ValuedOptionOnlineTime[string]())
+	assert.Equal(t, restored.Restore(s), true)
+	assert.Equal(t, restored.Remaining().Round(time.Second), time.Minute)
+
+	// Wall-time cooldown has expired while the process was down.
+	var offline []string
+	restored = cooldown.NewValued[string](cooldown.ValuedOptionHandler[string](stopRecorder{offline: &offline}))
+	assert.Equal(t, restored.Restore(s), false)
+	assert.Equal(t, offline, []string{"value"})
+}
@@ -0,0 +1,207 @@
+package sqlstore_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// fakeDriver is database/sql driver that understands only the queries of the
+// Store, keeping rows in memory. Databases are identified by DSN.
+type fakeDriver struct {
+	mu  sync.Mutex
+	dbs map[string]*fakeDB
+}
+
+type fakeDB struct {
+	mu      sync.Mutex
+	rows    map[string][]driver.Value
+	queries []string
+}
+
+var fake = &fakeDriver{dbs: make(map[string]*fakeDB)}
+
+func init() {
+	sql.Register("fake", fake)
+}
+
+func (d *fakeDriver) Open(dsn string) (driver.Conn, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	db, ok := d.dbs[dsn]
+	if !ok {
+		db = &fakeDB{rows: make(map[string][]driver.Value)}
+		d.dbs[dsn] = db
+	}
+	return &fakeConn{db: db}, nil
+}
+
+type fakeConn struct {
+	db *fakeDB
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{db: c.db, query: query}, nil
+}
+
+func (c *fakeConn) Close() error {
+	return nil
+}
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("transactions are not supported")
+}
+
+type fakeStmt struct {
+	db    *fakeDB
+	query string
+}
+
+func (s *fakeStmt) Close() error {
+	return nil
+}
+
+func (s *fakeStmt) NumInput() int {
+	return -1
+}
+
+type fakeResult int64
+
+func (fakeResult) LastInsertId() (int64, error) {
+	return 0, errors.New("not supported")
+}
+
+func (r fakeResult) RowsAffected() (int64, error) {
+	return int64(r), nil
+}
+
+// Exec executes INSERT, UPDATE, DELETE and CREATE TABLE queries. Rows contain
+// columns op, expiration, paused_at, duration, value and version.
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	db := s.db
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.queries = append(db.queries, s.query)
+
+	switch {
+	case strings.HasPrefix(s.query, "CREATE TABLE"):
+		return fakeResult(0), nil
+	case strings.HasPrefix(s.query, "INSERT"):
+		key := args[0].(string)
+		if _, ok := db.rows[key]; ok {
+			return fakeResult(0), nil
+		}
+		db.rows[key] = cloneRow(args[1:])
+		return fakeResult(1), nil
+	case strings.HasPrefix(s.query, "UPDATE"):
+		key, version := args[6].(string), args[7]
+		row, ok := db.rows[key]
+		if !ok || row[5] != version {
+			return fakeResult(0), nil
+		}
+		db.rows[key] = cloneRow(args[:6])
+		return fakeResult(1), nil
+	case strings.HasPrefix(s.query, "DELETE"):
+		key := args[0].(string)
+		if _, ok := db.rows[key]; !ok {
+			return fakeResult(0), nil
+		}
+		delete(db.rows, key)
+		return fakeResult(1), nil
+	}
+	return nil, fmt.Errorf("unsupported query: %s", s.query)
+}
+
+// Query executes SELECT queries by key or by key range.
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	db := s.db
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.queries = append(db.queries, s.query)
+
+	columns := []string{"op", "expiration", "paused_at", "duration", "value", "version"}
+	switch {
+	case strings.HasPrefix(s.query, "SELECT key"):
+		from, to := args[0].(string), ""
+		if len(args) > 1 {
+			to = args[1].(string)
+		}
+		if !utf8.ValidString(from) || !utf8.ValidString(to) {
+			// Like PostgreSQL, text parameters must be valid UTF-8.
+			return nil, errors.New("invalid byte sequence for encoding UTF8")
+		}
+		var keys []string
+		for key := range db.rows {
+			if key >= from && (to == "" || key < to) {
+				keys = append(keys, key)
+			}
+		}
+		slices.Sort(keys)
+		rows := &fakeRows{columns: append([]string{"key"}, columns...)}
+		for _, key := range keys {
+			rows.rows = append(rows.rows, append([]driver.Value{key}, cloneRow(db.rows[key])...))
+		}
+		return rows, nil
+	case strings.HasPrefix(s.query, "SELECT"):
+		rows := &fakeRows{columns: columns}
+		if row, ok := db.rows[args[0].(string)]; ok {
+			rows.rows = append(rows.rows, cloneRow(row))
+		}
+		return rows, nil
+	}
+	return nil, fmt.Errorf("unsupported query: %s", s.query)
+}
+
+func cloneRow(row []driver.Value) []driver.Value {
+	res := make([]driver.Value, len(row))
+	for i, v := range row {
+		if b, ok := v.([]byte); ok {
+			v = slices.Clone(b)
+		}
+		res[i] = v
+	}
+	return res
+}
+
+type fakeRows struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string {
+	return r.columns
+}
+
+func (r *fakeRows) Close() error {
+	return nil
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+// openFake opens new empty fake database.
+func openFake(name string) (*sql.DB, *fakeDB, error) {
+	db, err := sql.Open("fake", name)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err = db.PingContext(context.Background()); err != nil {
+		return nil, nil, err
+	}
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	return db, fake.dbs[name], nil
+}
@@ -0,0 +1,291 @@
+// Package sqlstore implements cooldown.Store over database/sql, e.g. to keep
+// cooldowns next to the player data in PostgreSQL or SQLite.
+//
+// Records are kept in a single table, see SchemaSQLite and SchemaPostgres.
+// Times are stored as Unix nanoseconds, zero for zero time. New records are
+// inserted with INSERT ... ON CONFLICT DO NOTHING, and existing ones are
+// updated only if their version didn't change since they were read, so
+// several processes can share the table without locks.
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/k4ties/cooldown"
+)
+
+// DefaultTable is the default name of the table with records.
+const DefaultTable = "cooldowns"
+
+// SchemaSQLite is the schema of the table for SQLite. %s is replaced with the
+// name of the table.
+const SchemaSQLite = `CREATE TABLE IF NOT EXISTS %s (
+	key        TEXT PRIMARY KEY,
+	op         INTEGER NOT NULL,
+	expiration INTEGER NOT NULL,
+	paused_at  INTEGER NOT NULL,
+	duration   INTEGER NOT NULL,
+	value      BLOB,
+	version    INTEGER NOT NULL
+)`
+
+// SchemaPostgres is the schema of the table for PostgreSQL. %s is replaced
+// with the name of the table. Keys use "C" collation, so they are ordered
+// bytewise, like in other stores.
+const SchemaPostgres = `CREATE TABLE IF NOT EXISTS %s (
+	key        TEXT COLLATE "C" PRIMARY KEY,
+	op         SMALLINT NOT NULL,
+	expiration BIGINT NOT NULL,
+	paused_at  BIGINT NOT NULL,
+	duration   BIGINT NOT NULL,
+	value      BYTEA,
+	version    BIGINT NOT NULL
+)`
+
+// Dialect is the SQL dialect of the database.
+type Dialect uint8
+
+const (
+	// SQLite uses ? placeholders and SchemaSQLite.
+	SQLite Dialect = iota
+	// Postgres uses $n placeholders and SchemaPostgres.
+	Postgres
+)
+
+// Option is the option of the Store.
+type Option func(s *Store)
+
+// OptionDialect sets the dialect of the database. Default is SQLite.
+func OptionDialect(d Dialect) Option {
+	return func(s *Store) {
+		s.dialect = d
+	}
+}
+
+// OptionTable sets the name of the table. It is inserted into queries as is,
+// so it must never come from untrusted input.
+func OptionTable(name string) Option {
+	return func(s *Store) {
+		if name != "" {
+			s.table = name
+		}
+	}
+}
+
+// Store is the cooldown.Store over *sql.DB.
+type Store struct {
+	db      *sql.DB
+	dialect Dialect
+	table   string
+
+	get, insert, update, remove, scan, scanAll string
+}
+
+// New creates new Store over provided database. The table must already exist,
+// see CreateTable.
+func New(db *sql.DB, opts ...Option) *Store {
+	s := &Store{db: db, table: DefaultTable}
+	for _, opt := range opts {
+		if opt == nil {
+			continue
+		}
+		opt(s)
+	}
+	const columns = "op, expiration, paused_at, duration, value, version"
+	s.get = s.query("SELECT %s FROM %s WHERE key = ?", columns, s.table)
+	s.insert = s.query("INSERT INTO %s (key, %s) VALUES (?, ?, ?, ?, ?, ?, ?) ON CONFLICT (key) DO NOTHING", s.table, columns)
+	s.update = s.query("UPDATE %s SET op = ?, expiration = ?, paused_at = ?, duration = ?, value = ?, version = ? WHERE key = ? AND version = ?", s.table)
+	s.remove = s.query("DELETE FROM %s WHERE key = ?", s.table)
+	s.scan = s.query("SELECT key, %s FROM %s WHERE key >= ? AND key < ? ORDER BY key", columns, s.table)
+	s.scanAll = s.query("SELECT key, %s FROM %s WHERE key >= ? ORDER BY key", columns, s.table)
+	return s
+}
+
+// query formats the query and replaces ? placeholders for the dialect.
+func (s *Store) query(format string, a ...any) string {
+	q := fmt.Sprintf(format, a...)
+	if s.dialect != Postgres {
+		return q
+	}
+	var (
+		b strings.Builder
+		n int
+	)
+	for _, r := range q {
+		if r == '?' {
+			n++
+			_, _ = fmt.Fprintf(&b, "$%d", n)
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// CreateTable creates the table with records if it doesn't exist.
+func (s *Store) CreateTable(ctx context.Context) error {
+	schema := SchemaSQLite
+	if s.dialect == Postgres {
+		schema = SchemaPostgres
+	}
+	_, err := s.db.ExecContext(ctx, fmt.Sprintf(schema, s.table))
+	return err
+}
+
+// Get ...
+func (s *Store) Get(ctx context.Context, key string) (cooldown.Record, bool, error) {
+	rec, err := scanRecord(s.db.QueryRowContext(ctx, s.get, key))
+	if errors.Is(err, sql.ErrNoRows) {
+		return cooldown.Record{}, false, nil
+	}
+	return rec, err == nil, err
+}
+
+// CompareAndSet ...
+func (s *Store) CompareAndSet(ctx context.Context, key string, version uint64, rec cooldown.Record) (uint64, error) {
+	var (
+		res sql.Result
+		err error
+	)
+	next := int64(version + 1)
+	args := []any{int64(rec.Op), unixNano(rec.Expiration), unixNano(rec.PausedAt), int64(rec.Duration), rec.Value, next}
+	if version == 0 {
+		res, err = s.db.ExecContext(ctx, s.insert, append([]any{key}, args...)...)
+	} else {
+		res, err = s.db.ExecContext(ctx, s.update, append(args, key, int64(version))...)
+	}
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if n == 0 {
+		return 0, cooldown.ErrVersionConflict
+	}
+	return uint64(next), nil
+}
+
+// Delete ...
+func (s *Store) Delete(ctx context.Context, key string) error {
+	_, err := s.db.ExecContext(ctx, s.remove, key)
+	return err
+}
+
+// ScanPrefix ...
+func (s *Store) ScanPrefix(ctx context.Context, prefix string, fn func(key string, rec cooldown.Record) bool) error {
+	var (
+		rows *sql.Rows
+		err  error
+	)
+	if end, ok := prefixEnd(prefix); ok && utf8.ValidString(prefix) && utf8.ValidString(end) {
+		rows, err = s.db.QueryContext(ctx, s.scan, prefix, end)
+	} else {
+		// Databases may reject invalid UTF-8 in the text parameters, so the
+		// scan starts at its valid part and the keys are filtered by the
+		// prefix below.
+		rows, err = s.db.QueryContext(ctx, s.scanAll, validPrefix(prefix))
+	}
+	if err != nil {
+		return err
+	}
+
+	// Rows are read before calling fn, so it can use the store even if the
+	// database has a single connection.
+	var (
+		keys    []string
+		records []cooldown.Record
+	)
+	for rows.Next() {
+		var key string
+		rec, err := scanRecord(rows, &key)
+		if err != nil {
+			_ = rows.Close()
+			return err
+		}
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		keys, records = append(keys, key), append(records, rec)
+	}
+	if err = rows.Close(); err != nil {
+		return err
+	}
+	if err = rows.Err(); err != nil {
+		return err
+	}
+	for i, key := range keys {
+		if !fn(key, records[i]) {
+			break
+		}
+	}
+	return nil
+}
+
+// validPrefix returns the longest prefix of s that is valid UTF-8. It is not
+// greater than s, so it can be used as the lower bound of the keys with
+// prefix s.
+func validPrefix(s string) string {
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size <= 1 {
+			return s[:i]
+		}
+		i += size
+	}
+	return s
+}
+
+// prefixEnd returns the smallest key greater than all the keys with provided
+// prefix. Ok is false if there is no such key.
+func prefixEnd(prefix string) (string, bool) {
+	b := []byte(prefix)
+	for i := len(b) - 1; i >= 0; i-- {
+		if b[i] < 0xff {
+			b[i]++
+			return string(b[:i+1]), true
+		}
+	}
+	return "", false
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanRecord(row scanner, dest ...any) (rec cooldown.Record, err error) {
+	var (
+		op                             int64
+		expiration, pausedAt, duration int64
+		version                        int64
+	)
+	dest = append(dest, &op, &expiration, &pausedAt, &duration, &rec.Value, &version)
+	if err = row.Scan(dest...); err != nil {
+		return rec, err
+	}
+	rec.Op = cooldown.Op(op)
+	rec.Expiration, rec.PausedAt = fromUnixNano(expiration), fromUnixNano(pausedAt)
+	rec.Duration, rec.Version = time.Duration(duration), uint64(version)
+	return rec, nil
+}
+
+func unixNano(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
+
+func fromUnixNano(n int64) time.Time {
+	if n == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, n)
+}
@@ -0,0 +1,100 @@
+package sqlstore_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/k4ties/cooldown"
+	"github.com/k4ties/cooldown/sqlstore"
+	"github.com/k4ties/cooldown/storetest"
+)
+
+func newStore(t *testing.T, opts ...sqlstore.Option) (*sqlstore.Store, *fakeDB) {
+	t.Helper()
+	db, fdb, err := openFake(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	s := sqlstore.New(db, opts...)
+	if err = s.CreateTable(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return s, fdb
+}
+
+func TestStore(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) cooldown.Store {
+		s, _ := newStore(t)
+		return s
+	})
+}
+
+func TestPostgres(t *testing.T) {
+	s, fdb := newStore(t, sqlstore.OptionDialect(sqlstore.Postgres), sqlstore.OptionTable("player_cooldowns"))
+	reg := cooldown.NewStoredRegistry[string](s, "player/")
+	ctx := context.Background()
+	if err := reg.Start(ctx, "a", time.Minute, "value"); err != nil {
+		t.Fatal(err)
+	}
+	if err := reg.Start(ctx, "a", time.Minute, "value"); err != nil {
+		t.Fatal(err)
+	}
+
+	var update string
+	for _, q := range fdb.queries {
+		if !strings.Contains(q, "player_cooldowns") || strings.Contains(q, "?") {
+			t.Fatalf("unexpected query: %s", q)
+		}
+		if strings.HasPrefix(q, "UPDATE") {
+			update = q
+		}
+	}
+	if !strings.Contains(update, "version = $8") {
+		t.Fatalf("placeholders are not numbered: %s", update)
+	}
+	if !strings.Contains(fdb.queries[0], `COLLATE "C"`) {
+		t.Fatalf("postgres schema is not used: %s", fdb.queries[0])
+	}
+}
+
+func TestScanPrefixInvalidBound(t *testing.T) {
+	s, fdb := newStore(t)
+	ctx := context.Background()
+	// Bound of the prefix ending with 0x7f would end with 0x80, which is not
+	// valid UTF-8.
+	for _, key := range []string{"a\x7f1", "a\x7f2", "b"} {
+		if _, err := s.CompareAndSet(ctx, key, 0, storetest.Record(cooldown.OpStart, key)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var keys []string
+	if err := s.ScanPrefix(ctx, "a\x7f", func(key string, _ cooldown.Record) bool {
+		keys = append(keys, key)
+		return true
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 2 || keys[0] != "a\x7f1" || keys[1] != "a\x7f2" {
+		t.Fatalf("ScanPrefix keys = %q, want [a\\x7f1 a\\x7f2]", keys)
+	}
+	for _, q := range fdb.queries {
+		if strings.Contains(q, "key < ") {
+			t.Fatalf("query with invalid bound: %s", q)
+		}
+	}
+
+	// Prefix itself is not valid UTF-8, though its bound "b" is.
+	keys = nil
+	if err := s.ScanPrefix(ctx, "a\xff", func(key string, _ cooldown.Record) bool {
+		keys = append(keys, key)
+		return true
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 0 {
+		t.Fatalf("ScanPrefix keys = %q, want none", keys)
+	}
+}
@@ -0,0 +1,280 @@
+package cooldown
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrVersionConflict is returned by Store.CompareAndSet if the record was
+// changed since it was read.
+var ErrVersionConflict = errors.New("record version conflict")
+
+// Op is the operation that produced the record.
+type Op uint8
+
+const (
+	// OpStart is the start of the cooldown.
+	OpStart Op = iota + 1
+	// OpRenew is the restart of the active cooldown with the same duration.
+	OpRenew
+	// OpPause is the pause of the cooldown.
+	OpPause
+	// OpResume is the resume of the paused cooldown.
+	OpResume
+	// OpStop is the stop of the cooldown before its expiration.
+	OpStop
+)
+
+func (op Op) String() string {
+	switch op {
+	case OpStart:
+		return "start"
+	case OpRenew:
+		return "renew"
+	case OpPause:
+		return "pause"
+	case OpResume:
+		return "resume"
+	case OpStop:
+		return "stop"
+	default:
+		return fmt.Sprintf("Op(%d)", uint8(op))
+	}
+}
+
+// Record is the state of the cooldown kept in the Store.
+type Record struct {
+	// Op is the last operation applied to the cooldown.
+	Op Op
+	// Expiration is the time cooldown expires at, see BasicState.
+	Expiration,
+	// PausedAt is the time cooldown was paused at, see BasicState.
+	PausedAt time.Time
+	// Duration is the duration cooldown was last started with.
+	Duration time.Duration
+	// Value is the encoded value cooldown was last started with.
+	Value []byte
+	// Version is incremented by the Store on every change of the record. It is
+	// never zero for stored records.
+	Version uint64
+}
+
+// StateAt returns state of the cooldown at provided time.
+func (rec Record) StateAt(now time.Time) BasicState {
+	return basicStateAt(rec.Expiration, rec.PausedAt, now)
+}
+
+// RemainingAt returns duration until the cooldown expires at provided time.
+func (rec Record) RemainingAt(now time.Time) time.Duration {
+	state := rec.StateAt(now)
+	if !state.Active {
+		return 0
+	}
+	if state.Paused {
+		return state.Expiration.Sub(state.PausedDate)
+	}
+	return state.Expiration.Sub(now)
+}
+
+// Store is the external storage of cooldown records, e.g. to share cooldowns
+// between several processes. Implementations must be safe for concurrent use.
+// The storetest package contains conformance tests for implementations.
+type Store interface {
+	// Get returns record of the key. Ok is false if there is no such record.
+	Get(ctx context.Context, key string) (rec Record, ok bool, err error)
+	// CompareAndSet stores the record only if version of the current record
+	// is equal to provided one. Zero version means the record must not exist.
+	// Version of provided record is ignored, the new one is returned instead.
+	// ErrVersionConflict is returned if versions don't match.
+	CompareAndSet(ctx context.Context, key string, version uint64, rec Record) (uint64, error)
+	// Delete removes record of the key. It is not an error if there is no
+	// such record.
+	Delete(ctx context.Context, key string) error
+	// ScanPrefix calls fn for every record with key starting with provided
+	// prefix in ascending order of keys, until it returns false.
+	ScanPrefix(ctx context.Context, prefix string, fn func(key string, rec Record) bool) error
+}
+
+// MemoryStore is in-memory Store implementation. It is mostly useful for
+// tests and as a reference for other implementations.
+type MemoryStore struct {
+	mu      sync.RWMutex
+	records map[string]Record
+}
+
+// NewMemoryStore creates new MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{records: make(map[string]Record)}
+}
+
+// Get ...
+func (s *MemoryStore) Get(_ context.Context, key string) (Record, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	rec, ok := s.records[key]
+	return cloneRecord(rec), ok, nil
+}
+
+// CompareAndSet ...
+func (s *MemoryStore) CompareAndSet(_ context.Context, key string, version uint64, rec Record) (uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.records[key].Version != version {
+		return 0, ErrVersionConflict
+	}
+	rec = cloneRecord(rec)
+	rec.Version = version + 1
+	s.records[key] = rec
+	return rec.Version, nil
+}
+
+// Delete ...
+func (s *MemoryStore) Delete(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, key)
+	return nil
+}
+
+// ScanPrefix ...
+func (s *MemoryStore) ScanPrefix(ctx context.Context, prefix string, fn func(key string, rec Record) bool) error {
+	s.mu.RLock()
+	keys := make([]string, 0, len(s.records))
+	for key := range s.records {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	s.mu.RUnlock()
+	slices.Sort(keys)
+
+	for _, key := range keys {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		rec, ok, _ := s.Get(ctx, key)
+		if !ok {
+			// Deleted during the scan.
+			continue
+		}
+		if !fn(key, rec) {
+			return nil
+		}
+	}
+	return nil
+}
+
+func cloneRecord(rec Record) Record {
+	rec.Value = slices.Clone(rec.Value)
+	return rec
+}
+
+// recordEncoding is the version of the binary encoding of the Record.
+const recordEncoding = 1
+
+// ErrInvalidRecord is returned when binary encoded record is malformed.
+var ErrInvalidRecord = errors.New("invalid record encoding")
+
+// MarshalBinary encodes the record into compact binary form.
+func (rec Record) MarshalBinary() ([]byte, error) {
+	return rec.AppendBinary(nil)
+}
+
+// AppendBinary appends binary form of the record to b.
+func (rec Record) AppendBinary(b []byte) ([]byte, error) {
+	b = append(b, recordEncoding, byte(rec.Op))
+	b = appendTime(b, rec.Expiration)
+	b = appendTime(b, rec.PausedAt)
+	b = binary.AppendVarint(b, int64(rec.Duration))
+	b = binary.AppendUvarint(b, rec.Version)
+	b = binary.AppendUvarint(b, uint64(len(rec.Value)))
+	return append(b, rec.Value...), nil
+}
+
+// UnmarshalBinary decodes the record from the form produced by MarshalBinary.
+func (rec *Record) UnmarshalBinary(data []byte) error {
+	if len(data) < 2 || data[0] != recordEncoding {
+		return ErrInvalidRecord
+	}
+	r := recordReader{data: data[2:]}
+	res := Record{
+		Op:         Op(data[1]),
+		Expiration: r.time(),
+		PausedAt:   r.time(),
+		Duration:   time.Duration(r.varint()),
+		Version:    r.uvarint(),
+	}
+	if n := r.uvarint(); r.err == nil {
+		if n > uint64(len(r.data)) {
+			return ErrInvalidRecord
+		}
+		if n > 0 {
+			res.Value = slices.Clone(r.data[:n])
+		}
+		r.data = r.data[n:]
+	}
+	if r.err != nil || len(r.data) != 0 {
+		return ErrInvalidRecord
+	}
+	*rec = res
+	return nil
+}
+
+// appendTime appends time as Unix nanoseconds, keeping zero time distinct
+// from the Unix epoch.
+func appendTime(b []byte, t time.Time) []byte {
+	if t.IsZero() {
+		return append(b, 0)
+	}
+	return binary.AppendVarint(append(b, 1), t.UnixNano())
+}
+
+type recordReader struct {
+	data []byte
+	err  error
+}
+
+func (r *recordReader) uvarint() uint64 {
+	if r.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(r.data)
+	if n <= 0 {
+		r.err = ErrInvalidRecord
+		return 0
+	}
+	r.data = r.data[n:]
+	return v
+}
+
+func (r *recordReader) varint() int64 {
+	if r.err != nil {
+		return 0
+	}
+	v, n := binary.Varint(r.data)
+	if n <= 0 {
+		r.err = ErrInvalidRecord
+		return 0
+	}
+	r.data = r.data[n:]
+	return v
+}
+
+func (r *recordReader) time() time.Time {
+	if r.err != nil || len(r.data) == 0 {
+		r.err = ErrInvalidRecord
+		return time.Time{}
+	}
+	set := r.data[0]
+	r.data = r.data[1:]
+	if set == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, r.varint())
+}
@@ -0,0 +1,87 @@
+package cooldown_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+	"github.com/k4ties/cooldown"
+	"github.com/k4ties/cooldown/storetest"
+)
+
+func TestMemoryStore(t *testing.T) {
+	storetest.Run(t, func(*testing.T) cooldown.Store {
+		return cooldown.NewMemoryStore()
+	})
+}
+
+func TestStoredRegistry(t *testing.T) {
+	ctx := context.Background()
+	clock := newFakeClock()
+	store := cooldown.NewMemoryStore()
+	reg := cooldown.NewStoredRegistry(store, "cd/", cooldown.StoredOptionClock[string](clock))
+	// Another process sharing the same store
+	other := cooldown.NewStoredRegistry(store, "cd/", cooldown.StoredOptionClock[string](clock))
+
+	assert.Equal(t, reg.TryStart(ctx, "a", time.Second*10, "first"), nil)
+	var onCooldown *cooldown.ErrOnCooldown
+	assert.Equal(t, errors.As(other.TryStart(ctx, "a", time.Second, "second"), &onCooldown), true)
+	assert.Equal(t, onCooldown.Remaining, time.Second*10)
+
+	val, ok, err := other.Value(ctx, "a")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, ok, true)
+	assert.Equal(t, val, "first")
+
+	clock.Advance(time.Second * 4)
+	paused, _ := other.Pause(ctx, "a")
+	assert.Equal(t, paused, true)
+	clock.Advance(time.Hour)
+	remaining, _ := reg.Remaining(ctx, "a")
+	assert.Equal(t, remaining, time.Second*6)
+	resumed, _ := reg.Resume(ctx, "a")
+	assert.Equal(t, resumed, true)
+	clock.Advance(time.Second)
+	remaining, _ = reg.Remaining(ctx, "a")
+	assert.Equal(t, remaining, time.Second*5)
+
+	renewed, _ := reg.Renew(ctx, "a")
+	assert.Equal(t, renewed, true)
+	remaining, _ = other.Remaining(ctx, "a")
+	assert.Equal(t, remaining, time.Second*10)
+
+	assert.Equal(t, other.Stop(ctx, "a"), nil)
+	active, _ := reg.Active(ctx, "a")
+	assert.Equal(t, active, false)
+	assert.Equal(t, reg.TryStart(ctx, "a", time.Second, "third"), nil)
+	assert.Equal(t, reg.Start(ctx, "b", 0, ""), cooldown.ErrStartCancelled)
+
+	var ops []cooldown.Op
+	assert.Equal(t, reg.Range(ctx, func(key string, rec cooldown.Record) bool {
+		assert.Equal(t, key, "a")
+		ops = append(ops, rec.Op)
+		return true
+	}), nil)
+	assert.Equal(t, ops, []cooldown.Op{cooldown.OpStart})
+}
+
+func TestRecordBinary(t *testing.T) {
+	for _, rec := range []cooldown.Record{
+		{},
+		storetest.Record(cooldown.OpResume, "value"),
+		{Op: cooldown.OpStart, Expiration: time.Unix(0, 0), Duration: -time.Second, Version: 1 << 40},
+	} {
+		b, err := rec.MarshalBinary()
+		assert.Equal(t, err, nil)
+		var got cooldown.Record
+		assert.Equal(t, got.UnmarshalBinary(b), nil)
+		assert.Equal(t, storetest.Equal(got, rec), nil)
+		assert.Equal(t, got.Version, rec.Version)
+		assert.Equal(t, got.Expiration.IsZero(), rec.Expiration.IsZero())
+
+		// Truncated data must be rejected
+		assert.Equal(t, got.UnmarshalBinary(b[:len(b)-1]), cooldown.ErrInvalidRecord)
+	}
+}
@@ -0,0 +1,214 @@
+package cooldown
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+// Codec encodes values of the cooldowns kept in the Store.
+type Codec[T any] interface {
+	Marshal(val T) ([]byte, error)
+	Unmarshal(data []byte, val *T) error
+}
+
+// JSONCodec is Codec using encoding/json.
+type JSONCodec[T any] struct{}
+
+// Marshal ...
+func (JSONCodec[T]) Marshal(val T) ([]byte, error) {
+	return json.Marshal(val)
+}
+
+// Unmarshal ...
+func (JSONCodec[T]) Unmarshal(data []byte, val *T) error {
+	return json.Unmarshal(data, val)
+}
+
+// StoredRegistry is keyed collection of cooldowns that keeps their state in
+// the Store, so it can be shared between processes. Like Basic, cooldowns are
+// lazy: there are no timers and handlers, the state is computed from the
+// record on every access. Records are changed with Store.CompareAndSet, and
+// changes are retried on conflicts.
+type StoredRegistry[T any] struct {
+	store  Store
+	prefix string
+	codec  Codec[T]
+	clock  Clock
+}
+
+// NewStoredRegistry creates new StoredRegistry. Keys of the records in the
+// store are prefixed with provided prefix, so several registries can share
+// the same store.
+func NewStoredRegistry[T any](store Store, prefix string, opts ...StoredOption[T]) *StoredRegistry[T] {
+	reg := &StoredRegistry[T]{
+		store:  store,
+		prefix: prefix,
+		codec:  JSONCodec[T]{},
+		clock:  SystemClock{},
+	}
+	for _, opt := range opts {
+		if opt == nil {
+			continue
+		}
+		opt(reg)
+	}
+	return reg
+}
+
+// Start starts cooldown with provided key, even if it is active.
+// ErrStartCancelled is returned if the duration is not positive.
+func (reg *StoredRegistry[T]) Start(ctx context.Context, key string, dur time.Duration, val T) error {
+	rec, err := reg.startRecord(dur, val)
+	if err != nil {
+		return err
+	}
+	_, err = reg.update(ctx, key, func(_ Record, now time.Time) (Record, bool, error) {
+		rec.Expiration = now.Add(dur)
+		return rec, true, nil
+	})
+	return err
+}
+
+// TryStart starts cooldown with provided key only if it is not active.
+// Returns *ErrOnCooldown if it is.
+func (reg *StoredRegistry[T]) TryStart(ctx context.Context, key string, dur time.Duration, val T) error {
+	rec, err := reg.startRecord(dur, val)
+	if err != nil {
+		return err
+	}
+	_, err = reg.update(ctx, key, func(cur Record, now time.Time) (Record, bool, error) {
+		if remaining := cur.RemainingAt(now); remaining > 0 {
+			return Record{}, false, &ErrOnCooldown{Remaining: remaining}
+		}
+		rec.Expiration = now.Add(dur)
+		return rec, true, nil
+	})
+	return err
+}
+
+func (reg *StoredRegistry[T]) startRecord(dur time.Duration, val T) (Record, error) {
+	if dur <= 0 {
+		return Record{}, ErrStartCancelled
+	}
+	data, err := reg.codec.Marshal(val)
+	if err != nil {
+		return Record{}, err
+	}
+	return Record{Op: OpStart, Duration: dur, Value: data}, nil
+}
+
+// Renew restarts active cooldown with the duration and value it was started
+// with. Returns false if the cooldown is not active.
+func (reg *StoredRegistry[T]) Renew(ctx context.Context, key string) (bool, error) {
+	return reg.update(ctx, key, func(rec Record, now time.Time) (Record, bool, error) {
+		if !rec.StateAt(now).Active {
+			return Record{}, false, nil
+		}
+		rec.Op, rec.Expiration, rec.PausedAt = OpRenew, now.Add(rec.Duration), time.Time{}
+		return rec, true, nil
+	})
+}
+
+// Pause pauses cooldown with provided key. Returns false if it is not active
+// or already paused.
+func (reg *StoredRegistry[T]) Pause(ctx context.Context, key string) (bool, error) {
+	return reg.update(ctx, key, func(rec Record, now time.Time) (Record, bool, error) {
+		if state := rec.StateAt(now); !state.Active || state.Paused {
+			return Record{}, false, nil
+		}
+		rec.Op, rec.PausedAt = OpPause, now
+		return rec, true, nil
+	})
+}
+
+// Resume resumes cooldown with provided key. Returns false if it is not
+// paused.
+func (reg *StoredRegistry[T]) Resume(ctx context.Context, key string) (bool, error) {
+	return reg.update(ctx, key, func(rec Record, now time.Time) (Record, bool, error) {
+		if rec.PausedAt.IsZero() {
+			return Record{}, false, nil
+		}
+		// Time spent in pause must not be counted.
+		rec.Expiration = rec.Expiration.Add(now.Sub(rec.PausedAt))
+		rec.Op, rec.PausedAt = OpResume, time.Time{}
+		return rec, true, nil
+	})
+}
+
+// Stop stops cooldown with provided key. The record is kept, so its version
+// keeps increasing, see Delete to remove it.
+func (reg *StoredRegistry[T]) Stop(ctx context.Context, key string) error {
+	_, err := reg.update(ctx, key, func(rec Record, now time.Time) (Record, bool, error) {
+		if rec.Version == 0 || !rec.StateAt(now).Active {
+			return Record{}, false, nil
+		}
+		rec.Op, rec.Expiration, rec.PausedAt = OpStop, time.Time{}, time.Time{}
+		return rec, true, nil
+	})
+	return err
+}
+
+// Delete removes record of the cooldown with provided key from the store.
+func (reg *StoredRegistry[T]) Delete(ctx context.Context, key string) error {
+	return reg.store.Delete(ctx, reg.prefix+key)
+}
+
+// Active returns true if cooldown with provided key is active.
+func (reg *StoredRegistry[T]) Active(ctx context.Context, key string) (bool, error) {
+	rec, _, err := reg.store.Get(ctx, reg.prefix+key)
+	return rec.StateAt(reg.clock.Now()).Active, err
+}
+
+// Remaining returns duration until cooldown with provided key expires.
+func (reg *StoredRegistry[T]) Remaining(ctx context.Context, key string) (time.Duration, error) {
+	rec, _, err := reg.store.Get(ctx, reg.prefix+key)
+	return rec.RemainingAt(reg.clock.Now()), err
+}
+
+// Value returns the value cooldown with provided key was last started with.
+// Ok is false if it was never started.
+func (reg *StoredRegistry[T]) Value(ctx context.Context, key string) (val T, ok bool, err error) {
+	rec, ok, err := reg.store.Get(ctx, reg.prefix+key)
+	if err != nil || !ok || rec.Value == nil {
+		return val, false, err
+	}
+	return val, true, reg.codec.Unmarshal(rec.Value, &val)
+}
+
+// Range calls fn for every record of the registry, until it returns false.
+// Keys are passed without the prefix.
+func (reg *StoredRegistry[T]) Range(ctx context.Context, fn func(key string, rec Record) bool) error {
+	return reg.store.ScanPrefix(ctx, reg.prefix, func(key string, rec Record) bool {
+		return fn(strings.TrimPrefix(key, reg.prefix), rec)
+	})
+}
+
+// Store returns the store the records are kept in.
+func (reg *StoredRegistry[T]) Store() Store {
+	return reg.store
+}
+
+// update applies fn to the record of the key until it is stored without
+// conflicts. If fn returns false or an error, the record is not changed.
+func (reg *StoredRegistry[T]) update(ctx context.Context, key string, fn func(rec Record, now time.Time) (Record, bool, error)) (bool, error) {
+	key = reg.prefix + key
+	for {
+		rec, _, err := reg.store.Get(ctx, key)
+		if err != nil {
+			return false, err
+		}
+		next, ok, err := fn(rec, reg.clock.Now())
+		if err != nil || !ok {
+			return false, err
+		}
+		if _, err = reg.store.CompareAndSet(ctx, key, rec.Version, next); !errors.Is(err, ErrVersionConflict) {
+			return err == nil, err
+		}
+		if err = ctx.Err(); err != nil {
+			return false, err
+		}
+	}
+}
@@ -0,0 +1,219 @@
+// Package storetest implements conformance tests for cooldown.Store
+// implementations.
+package storetest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/k4ties/cooldown"
+)
+
+// Run runs conformance tests against stores created by newStore. Every test
+// creates a new store, and stores must be empty when created.
+func Run(t *testing.T, newStore func(t *testing.T) cooldown.Store) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, s cooldown.Store)
+	}{
+		{"Get", testGet},
+		{"CompareAndSet", testCompareAndSet},
+		{"Delete", testDelete},
+		{"ScanPrefix", testScanPrefix},
+		{"Concurrent", testConcurrent},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.fn(t, newStore(t))
+		})
+	}
+}
+
+// Record returns record with all the fields set, that can be compared with
+// Equal after being stored.
+func Record(op cooldown.Op, value string) cooldown.Record {
+	at := time.Unix(1700000000, 123456789)
+	return cooldown.Record{
+		Op:         op,
+		Expiration: at.Add(time.Minute),
+		PausedAt:   at,
+		Duration:   time.Minute,
+		Value:      []byte(value),
+	}
+}
+
+// Equal returns an error if records are not equal. Versions are compared
+// only if want has non-zero version.
+func Equal(got, want cooldown.Record) error {
+	switch {
+	case got.Op != want.Op:
+		return fmt.Errorf("op = %v, want %v", got.Op, want.Op)
+	case !got.Expiration.Equal(want.Expiration):
+		return fmt.Errorf("expiration = %v, want %v", got.Expiration, want.Expiration)
+	case !got.PausedAt.Equal(want.PausedAt):
+		return fmt.Errorf("paused at = %v, want %v", got.PausedAt, want.PausedAt)
+	case got.Duration != want.Duration:
+		return fmt.Errorf("duration = %v, want %v", got.Duration, want.Duration)
+	case string(got.Value) != string(want.Value):
+		return fmt.Errorf("value = %q, want %q", got.Value, want.Value)
+	case want.Version != 0 && got.Version != want.Version:
+		return fmt.Errorf("version = %d, want %d", got.Version, want.Version)
+	}
+	return nil
+}
+
+func mustGet(t *testing.T, s cooldown.Store, key string) cooldown.Record {
+	t.Helper()
+	rec, ok, err := s.Get(context.Background(), key)
+	if err != nil {
+		t.Fatalf("Get(%q): %v", key, err)
+	}
+	if !ok {
+		t.Fatalf("Get(%q): record not found", key)
+	}
+	return rec
+}
+
+func testGet(t *testing.T, s cooldown.Store) {
+	ctx := context.Background()
+	if _, ok, err := s.Get(ctx, "missing"); err != nil || ok {
+		t.Fatalf("Get(missing) = %t, %v, want false, nil", ok, err)
+	}
+
+	want := Record(cooldown.OpPause, "value")
+	if _, err := s.CompareAndSet(ctx, "key", 0, want); err != nil {
+		t.Fatalf("CompareAndSet: %v", err)
+	}
+	want.Version = 1
+	if err := Equal(mustGet(t, s, "key"), want); err != nil {
+		t.Fatal(err)
+	}
+
+	// Zero times and empty values must be kept as is.
+	if _, err := s.CompareAndSet(ctx, "zero", 0, cooldown.Record{Op: cooldown.OpStop}); err != nil {
+		t.Fatalf("CompareAndSet: %v", err)
+	}
+	rec := mustGet(t, s, "zero")
+	if !rec.Expiration.IsZero() || !rec.PausedAt.IsZero() || len(rec.Value) != 0 {
+		t.Fatalf("zero record = %+v", rec)
+	}
+}
+
+func testCompareAndSet(t *testing.T, s cooldown.Store) {
+	ctx := context.Background()
+	version, err := s.CompareAndSet(ctx, "key", 0, Record(cooldown.OpStart, "a"))
+	if err != nil || version != 1 {
+		t.Fatalf("CompareAndSet(create) = %d, %v, want 1, nil", version, err)
+	}
+	if _, err = s.CompareAndSet(ctx, "key", 0, Record(cooldown.OpStart, "b")); !errors.Is(err, cooldown.ErrVersionConflict) {
+		t.Fatalf("CompareAndSet(create existing) = %v, want ErrVersionConflict", err)
+	}
+	if _, err = s.CompareAndSet(ctx, "key", 2, Record(cooldown.OpStart, "b")); !errors.Is(err, cooldown.ErrVersionConflict) {
+		t.Fatalf("CompareAndSet(wrong version) = %v, want ErrVersionConflict", err)
+	}
+	if _, err = s.CompareAndSet(ctx, "other", 1, Record(cooldown.OpStart, "b")); !errors.Is(err, cooldown.ErrVersionConflict) {
+		t.Fatalf("CompareAndSet(missing) = %v, want ErrVersionConflict", err)
+	}
+
+	want := Record(cooldown.OpRenew, "b")
+	if version, err = s.CompareAndSet(ctx, "key", 1, want); err != nil || version != 2 {
+		t.Fatalf("CompareAndSet(update) = %d, %v, want 2, nil", version, err)
+	}
+	want.Version = 2
+	if err = Equal(mustGet(t, s, "key"), want); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func testDelete(t *testing.T, s cooldown.Store) {
+	ctx := context.Background()
+	if err := s.Delete(ctx, "missing"); err != nil {
+		t.Fatalf("Delete(missing): %v", err)
+	}
+	if _, err := s.CompareAndSet(ctx, "key", 0, Record(cooldown.OpStart, "a")); err != nil {
+		t.Fatalf("CompareAndSet: %v", err)
+	}
+	if err := s.Delete(ctx, "key"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, ok, err := s.Get(ctx, "key"); err != nil || ok {
+		t.Fatalf("Get(deleted) = %t, %v, want false, nil", ok, err)
+	}
+	if _, err := s.CompareAndSet(ctx, "key", 0, Record(cooldown.OpStart, "a")); err != nil {
+		t.Fatalf("CompareAndSet(recreate): %v", err)
+	}
+}
+
+func testScanPrefix(t *testing.T, s cooldown.Store) {
+	ctx := context.Background()
+	for _, key := range []string{"b/2", "a/1", "b/1", "b/3", "c/1"} {
+		if _, err := s.CompareAndSet(ctx, key, 0, Record(cooldown.OpStart, key)); err != nil {
+			t.Fatalf("CompareAndSet(%q): %v", key, err)
+		}
+	}
+
+	var keys []string
+	err := s.ScanPrefix(ctx, "b/", func(key string, rec cooldown.Record) bool {
+		if string(rec.Value) != key {
+			t.Errorf("record of %q has value %q", key, rec.Value)
+		}
+		keys = append(keys, key)
+		return true
+	})
+	if err != nil {
+		t.Fatalf("ScanPrefix: %v", err)
+	}
+	if fmt.Sprint(keys) != "[b/1 b/2 b/3]" {
+		t.Fatalf("ScanPrefix keys = %v, want [b/1 b/2 b/3]", keys)
+	}
+
+	keys = nil
+	if err = s.ScanPrefix(ctx, "", func(key string, _ cooldown.Record) bool {
+		keys = append(keys, key)
+		return len(keys) < 2
+	}); err != nil {
+		t.Fatalf("ScanPrefix: %v", err)
+	}
+	if fmt.Sprint(keys) != "[a/1 b/1]" {
+		t.Fatalf("ScanPrefix keys = %v, want [a/1 b/1]", keys)
+	}
+}
+
+func testConcurrent(t *testing.T, s cooldown.Store) {
+	const workers, increments = 8, 25
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range increments {
+				for {
+					rec, _, err := s.Get(ctx, "counter")
+					if err != nil {
+						t.Errorf("Get: %v", err)
+						return
+					}
+					rec.Duration++
+					if _, err = s.CompareAndSet(ctx, "counter", rec.Version, rec); err == nil {
+						break
+					} else if !errors.Is(err, cooldown.ErrVersionConflict) {
+						t.Errorf("CompareAndSet: %v", err)
+						return
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	rec := mustGet(t, s, "counter")
+	if rec.Duration != workers*increments || rec.Version != workers*increments {
+		t.Fatalf("counter = %d, version = %d, want %d", rec.Duration, rec.Version, workers*increments)
+	}
+}
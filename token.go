@@ -0,0 +1,294 @@
+package cooldown
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrTokenInvalid is returned when the token is malformed, forged, signed
+	// with unknown key or issued for another cooldown key.
+	ErrTokenInvalid = errors.New("cooldown: invalid token")
+	// ErrTokenExpired is returned when the token was issued earlier than the
+	// maximum age of the tokens.
+	ErrTokenExpired = errors.New("cooldown: token expired")
+	// ErrTokenReplayed is returned when newer token of the same cooldown key
+	// was already issued or verified.
+	ErrTokenReplayed = errors.New("cooldown: token replayed")
+)
+
+const tokenVersion = 1
+
+// TokenKey is the key tokens are signed with.
+type TokenKey struct {
+	// ID identifies the key in the token, so it can be verified after the
+	// key is rotated.
+	ID string
+	// Secret is the HMAC-SHA256 secret. It should be at least 32 bytes long.
+	Secret []byte
+}
+
+// Tokens encodes states of the cooldowns into signed tokens, so stateless
+// services can give them to the clients instead of storing them. Tokens are
+// signed with HMAC-SHA256 and bound to the cooldown key, so clients can't
+// forge them or use tokens of each other.
+//
+// Since tokens are stateless, the client can present the older token of the
+// cooldown instead of the newest one. Tokens are valid only within the maximum
+// age, and Tokens remembers the newest token of every key it has issued or
+// verified within the age and rejects the older ones. Services sharing the
+// keys don't share this memory, so the client may still replay tokens to the
+// different service within the maximum age.
+type Tokens struct {
+	mu sync.Mutex
+	// keys are the keys used for verification, the last of them is used for
+	// signing.
+	keys  []TokenKey
+	clock Clock
+
+	maxAge time.Duration
+	// latest contains issue times of the newest tokens of the keys.
+	latest map[string]int64
+	// pruned is the time latest was last pruned at.
+	pruned int64
+}
+
+// NewTokens creates new Tokens signing the tokens with provided key. Tokens
+// issued earlier than maxAge ago are expired. It must be longer than the
+// longest cooldown, since the client may present the token issued before the
+// cooldown was started once its newer tokens expire. If maxAge is not
+// positive, all the tokens are expired.
+func NewTokens(key TokenKey, maxAge time.Duration, opts ...TokenOption) *Tokens {
+	t := &Tokens{
+		keys:   []TokenKey{key},
+		clock:  SystemClock{},
+		maxAge: max(maxAge, 0),
+		latest: make(map[string]int64),
+	}
+	for _, opt := range opts {
+		if opt == nil {
+			continue
+		}
+		opt(t)
+	}
+	return t
+}
+
+// Rotate makes provided key the signing key. Previous keys are still used
+// to verify the tokens, until they are retired.
+func (t *Tokens) Rotate(key TokenKey) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.keys = slices.DeleteFunc(t.keys, func(k TokenKey) bool {
+		return k.ID == key.ID
+	})
+	t.keys = append(t.keys, key)
+}
+
+// Retire removes the key with provided ID, so tokens signed with it are not
+// valid anymore. The signing key can't be retired. Returns false if there is
+// no such key or it is the signing key.
+func (t *Tokens) Retire(id string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	i := slices.IndexFunc(t.keys, func(k TokenKey) bool {
+		return k.ID == id
+	})
+	if i < 0 || i == len(t.keys)-1 {
+		return false
+	}
+	t.keys = slices.Delete(t.keys, i, i+1)
+	return true
+}
+
+// Encode encodes state of the cooldown with provided key into signed token.
+// Active field of the state is ignored, it is computed from the dates.
+func (t *Tokens) Encode(key string, state BasicState) string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	signing := t.keys[len(t.keys)-1]
+
+	issued := t.clock.Now().UnixNano()
+	t.pruneUnsafe(issued)
+	// Tokens of the same key are ordered by the issue time, so it must be
+	// unique.
+	issued = max(issued, t.latest[key]+1)
+	t.latest[key] = issued
+	var pausedAt int64
+	if state.Paused {
+		pausedAt = unixNano(state.PausedDate)
+	}
+
+	payload := []byte{tokenVersion}
+	payload = appendTokenString(payload, signing.ID)
+	payload = appendTokenString(payload, key)
+	payload = binary.BigEndian.AppendUint64(payload, uint64(unixNano(state.Expiration)))
+	payload = binary.BigEndian.AppendUint64(payload, uint64(pausedAt))
+	payload = binary.BigEndian.AppendUint64(payload, uint64(issued))
+
+	return base64.RawURLEncoding.EncodeToString(payload) + "." +
+		base64.RawURLEncoding.EncodeToString(sign(signing.Secret, payload))
+}
+
+// Issue starts new cooldown with provided key and duration and returns its
+// token.
+func (t *Tokens) Issue(key string, dur time.Duration) string {
+	var state BasicState
+	if dur > 0 {
+		state.Expiration = t.clock.Now().Add(dur)
+	}
+	return t.Encode(key, state)
+}
+
+// Verify verifies that the token was issued for the cooldown with provided
+// key and returns its state. Returns ErrTokenInvalid if the token is forged,
+// and ErrTokenExpired or ErrTokenReplayed if it is too old.
+func (t *Tokens) Verify(token, key string) (*ReadOnlyBasic, error) {
+	enc, encSig, ok := strings.Cut(token, ".")
+	if !ok {
+		return nil, ErrTokenInvalid
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(enc)
+	if err != nil {
+		return nil, ErrTokenInvalid
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(encSig)
+	if err != nil {
+		return nil, ErrTokenInvalid
+	}
+	tok, ok := parseToken(payload)
+	if !ok {
+		return nil, ErrTokenInvalid
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	i := slices.IndexFunc(t.keys, func(k TokenKey) bool {
+		return k.ID == tok.keyID
+	})
+	if i < 0 || !hmac.Equal(sig, sign(t.keys[i].Secret, payload)) || tok.key != key {
+		return nil, ErrTokenInvalid
+	}
+	now := t.clock.Now().UnixNano()
+	if t.maxAge == 0 || tok.issued <= now-int64(t.maxAge) {
+		return nil, ErrTokenExpired
+	}
+	t.pruneUnsafe(now)
+	if tok.issued < t.latest[key] {
+		return nil, ErrTokenReplayed
+	}
+	t.latest[key] = tok.issued
+
+	cd := &ReadOnlyBasic{}
+	cd.basic.clock = t.clock
+	cd.basic.expiration, cd.basic.pausedAt = fromUnixNano(tok.expiration), fromUnixNano(tok.pausedAt)
+	return cd, nil
+}
+
+// pruneUnsafe removes issue times of the tokens older than the maximum age.
+// It walks all the keys at most once per the maximum age, so the removed
+// times are at most twice as old. They are still older than any token that
+// hasn't expired, so they never reject one.
+func (t *Tokens) pruneUnsafe(now int64) {
+	if now-t.pruned < int64(t.maxAge) {
+		return
+	}
+	t.pruned = now
+	for key, issued := range t.latest {
+		if issued <= now-int64(t.maxAge) {
+			delete(t.latest, key)
+		}
+	}
+}
+
+// ReadOnlyBasic is the Basic cooldown which state can't be changed, e.g.
+// verified from the token.
+type ReadOnlyBasic struct {
+	basic Basic
+}
+
+// Active returns true if cooldown is currently active.
+func (cooldown *ReadOnlyBasic) Active() bool {
+	return cooldown.basic.Active()
+}
+
+// Remaining returns duration until cooldown expiration.
+func (cooldown *ReadOnlyBasic) Remaining() time.Duration {
+	return cooldown.basic.Remaining()
+}
+
+// Paused returns true if cooldown is paused.
+func (cooldown *ReadOnlyBasic) Paused() bool {
+	return cooldown.basic.Paused()
+}
+
+// State returns the current state of the cooldown.
+func (cooldown *ReadOnlyBasic) State() BasicState {
+	return cooldown.basic.State()
+}
+
+type token struct {
+	keyID, key                   string
+	expiration, pausedAt, issued int64
+}
+
+func parseToken(b []byte) (tok token, ok bool) {
+	if len(b) == 0 || b[0] != tokenVersion {
+		return tok, false
+	}
+	b = b[1:]
+	if tok.keyID, b, ok = readTokenString(b); !ok {
+		return tok, false
+	}
+	if tok.key, b, ok = readTokenString(b); !ok {
+		return tok, false
+	}
+	if len(b) != 24 {
+		return tok, false
+	}
+	tok.expiration = int64(binary.BigEndian.Uint64(b))
+	tok.pausedAt = int64(binary.BigEndian.Uint64(b[8:]))
+	tok.issued = int64(binary.BigEndian.Uint64(b[16:]))
+	return tok, true
+}
+
+func appendTokenString(b []byte, s string) []byte {
+	return append(binary.AppendUvarint(b, uint64(len(s))), s...)
+}
+
+func readTokenString(b []byte) (string, []byte, bool) {
+	n, size := binary.Uvarint(b)
+	if size <= 0 || n > uint64(len(b)-size) {
+		return "", nil, false
+	}
+	b = b[size:]
+	return string(b[:n]), b[n:], true
+}
+
+func sign(secret, payload []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+// unixNano returns Unix time of t in nanoseconds, or 0 if t is zero.
+func unixNano(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
+
+func fromUnixNano(n int64) time.Time {
+	if n == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, n)
+}
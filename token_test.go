@@ -0,0 +1,126 @@
+package cooldown_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+	"github.com/k4ties/cooldown"
+)
+
+var (
+	tokenKey1 = cooldown.TokenKey{ID: "1", Secret: []byte("0123456789abcdef0123456789abcdef")}
+	tokenKey2 = cooldown.TokenKey{ID: "2", Secret: []byte("fedcba9876543210fedcba9876543210")}
+)
+
+func TestTokens(t *testing.T) {
+	tokens := cooldown.NewTokens(tokenKey1, time.Hour)
+	cd, err := tokens.Verify(tokens.Issue("user", time.Minute), "user")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, cd.Active(), true)
+	assert.Equal(t, cd.Paused(), false)
+	assert.Equal(t, cd.Remaining() > time.Second*59, true)
+
+	basic := new(cooldown.Basic)
+	basic.Set(time.Minute)
+	basic.Pause()
+	cd, err = tokens.Verify(tokens.Encode("user", basic.State()), "user")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, cd.State().Expiration.Equal(basic.State().Expiration), true)
+	assert.Equal(t, cd.State().PausedDate.Equal(basic.State().PausedDate), true)
+	assert.Equal(t, cd.Remaining().Round(time.Millisecond), basic.Remaining().Round(time.Millisecond))
+
+	cd, err = tokens.Verify(tokens.Issue("user", 0), "user")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, cd.Active(), false)
+}
+
+func TestTokensForged(t *testing.T) {
+	tokens := cooldown.NewTokens(tokenKey1, time.Hour)
+	token := tokens.Issue("user", time.Minute)
+
+	// Token of another user.
+	_, err := tokens.Verify(token, "other")
+	assert.Equal(t, err, cooldown.ErrTokenInvalid)
+
+	// Token signed with another secret.
+	forged := cooldown.NewTokens(cooldown.TokenKey{ID: "1", Secret: []byte("guess")}, time.Hour).Issue("user", 0)
+	_, err = tokens.Verify(forged, "user")
+	assert.Equal(t, err, cooldown.ErrTokenInvalid)
+
+	// Payload of the inactive token with signature of the active one.
+	payload, _, _ := strings.Cut(tokens.Issue("user", 0), ".")
+	_, sig, _ := strings.Cut(token, ".")
+	_, err = tokens.Verify(payload+"."+sig, "user")
+	assert.Equal(t, err, cooldown.ErrTokenInvalid)
+
+	for _, malformed := range []string{"", ".", "token", "!." + sig, payload + ".!", payload[:len(payload)-4] + "." + sig} {
+		_, err = tokens.Verify(malformed, "user")
+		assert.Equal(t, err, cooldown.ErrTokenInvalid)
+	}
+}
+
+func TestTokensRotation(t *testing.T) {
+	tokens := cooldown.NewTokens(tokenKey1, time.Hour)
+	old := tokens.Issue("user", time.Minute)
+
+	tokens.Rotate(tokenKey2)
+	_, err := tokens.Verify(old, "user")
+	assert.Equal(t, err, nil)
+
+	// New tokens are signed with the new key only.
+	_, err = cooldown.NewTokens(tokenKey1, time.Hour).Verify(tokens.Issue("user", time.Minute), "user")
+	assert.Equal(t, err, cooldown.ErrTokenInvalid)
+
+	assert.Equal(t, tokens.Retire("2"), false)
+	assert.Equal(t, tokens.Retire("unknown"), false)
+	assert.Equal(t, tokens.Retire("1"), true)
+	_, err = tokens.Verify(old, "user")
+	assert.Equal(t, err, cooldown.ErrTokenInvalid)
+}
+
+func TestTokensReplay(t *testing.T) {
+	clock := newFakeClock()
+	tokens := cooldown.NewTokens(tokenKey1, time.Hour, cooldown.TokenOptionClock(clock))
+
+	inactive := tokens.Issue("user", 0)
+	_, err := tokens.Verify(inactive, "user")
+	assert.Equal(t, err, nil)
+	// The same token may be presented several times.
+	_, err = tokens.Verify(inactive, "user")
+	assert.Equal(t, err, nil)
+
+	// After the newer token is issued, the older one is replayed, even if
+	// they are issued at the same time.
+	active := tokens.Issue("user", time.Minute)
+	_, err = tokens.Verify(inactive, "user")
+	assert.Equal(t, err, cooldown.ErrTokenReplayed)
+	_, err = tokens.Verify(active, "user")
+	assert.Equal(t, err, nil)
+
+	// Tokens of other keys are not affected.
+	_, err = tokens.Verify(tokens.Issue("other", 0), "other")
+	assert.Equal(t, err, nil)
+
+	clock.Advance(time.Hour + time.Second)
+	_, err = tokens.Verify(active, "user")
+	assert.Equal(t, err, cooldown.ErrTokenExpired)
+	_, err = tokens.Verify(tokens.Issue("user", 0), "user")
+	assert.Equal(t, err, nil)
+
+	// Issued cooldowns are started on the clock time.
+	cd, err := tokens.Verify(tokens.Issue("user", time.Minute), "user")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, cd.State().Expiration.Equal(clock.Now().Add(time.Minute)), true)
+	// State of the verified cooldown is computed on the clock time too.
+	assert.Equal(t, cd.Active(), true)
+	assert.Equal(t, cd.Remaining(), time.Minute)
+}
+
+func TestTokensMaxAge(t *testing.T) {
+	// Tokens can't be verified without the maximum age.
+	tokens := cooldown.NewTokens(tokenKey1, 0)
+	_, err := tokens.Verify(tokens.Issue("user", time.Minute), "user")
+	assert.Equal(t, err, cooldown.ErrTokenExpired)
+}
@@ -0,0 +1,189 @@
+package cooldown
+
+import (
+	"time"
+
+	"github.com/k4ties/cooldown/internal/event"
+)
+
+// ReadyWithTolerance returns true if cooldown is inactive or expires within
+// provided tolerance, e.g. the latency of the client. Paused cooldown is never
+// ready, since it doesn't expire.
+func (cooldown *Basic) ReadyWithTolerance(tol time.Duration) bool {
+	cooldown.L.RLock()
+	defer cooldown.L.RUnlock()
+	return cooldown.ReadyWithToleranceUnsafe(tol)
+}
+
+func (cooldown *Basic) ReadyWithToleranceUnsafe(tol time.Duration) bool {
+	state := cooldown.StateUnsafe()
+	return !state.Active || !state.Paused && cooldown.RemainingUnsafe() <= tol
+}
+
+// TryStartWithTolerance works like TryStart, but also starts the cooldown if it
+// expires within provided tolerance. Early is the duration that was left until
+// expiration, it is positive only if the start passed because of the
+// tolerance, so such starts can be recorded.
+func (cooldown *Basic) TryStartWithTolerance(dur, tol time.Duration) (started bool, early time.Duration) {
+	cooldown.L.Lock()
+	defer cooldown.L.Unlock()
+	return cooldown.TryStartWithToleranceUnsafe(dur, tol)
+}
+
+func (cooldown *Basic) TryStartWithToleranceUnsafe(dur, tol time.Duration) (started bool, early time.Duration) {
+	if dur <= 0 || !cooldown.ReadyWithToleranceUnsafe(tol) {
+		return false, 0
+	}
+	if cooldown.ActiveUnsafe() {
+		early = cooldown.RemainingUnsafe()
+	}
+	cooldown.SetUnsafe(dur)
+	return true, early
+}
+
+// ReadyWithTolerance ...
+func (cooldown *Valued[T]) ReadyWithTolerance(tol time.Duration) bool {
+	cooldown.mu.RLock()
+	defer cooldown.mu.RUnlock()
+	return cooldown.ReadyWithToleranceUnsafe(tol)
+}
+
+func (cooldown *Valued[T]) ReadyWithToleranceUnsafe(tol time.Duration) bool {
+	if !cooldown.ActiveUnsafe() {
+		return true
+	}
+	return !cooldown.PausedUnsafe() && cooldown.RemainingUnsafe() <= tol
+}
+
+// TryStartWithTolerance works like Basic.TryStartWithTolerance. If the
+// cooldown is still active, it expires right before the new start, like the
+// timer had already fired: HandleStop is called with ErrStopCauseExpired and
+// the value the cooldown was started with. It happens only after the start
+// passed HandleStart, so the cooldown is kept if the start is cancelled.
+func (cooldown *Valued[T]) TryStartWithTolerance(dur, tol time.Duration, val T) (started bool, early time.Duration) {
+	defer cooldown.lockStart()()
+	return cooldown.TryStartWithToleranceUnsafe(dur, tol, val)
+}
+
+func (cooldown *Valued[T]) TryStartWithToleranceUnsafe(dur, tol time.Duration, val T) (started bool, early time.Duration) {
+	if !cooldown.ReadyWithToleranceUnsafe(tol) {
+		cooldown.violateUnsafe()
+		return false, 0
+	}
+	early, err := cooldown.startTolerantUnsafe(dur, val)
+	if err != nil {
+		return false, 0
+	}
+	return true, early
+}
+
+// startTolerantUnsafe starts the cooldown that is ready with tolerance and
+// returns the duration it had left, see TryStartWithTolerance.
+func (cooldown *Valued[T]) startTolerantUnsafe(dur time.Duration, val T) (early time.Duration, err error) {
+	if !cooldown.ActiveUnsafe() {
+		return 0, cooldown.startUnsafe(dur, val)
+	}
+	if dur <= 0 {
+		return 0, ErrStartCancelled
+	}
+	if cooldown.check != nil {
+		if err := cooldown.check(val); err != nil {
+			return 0, err
+		}
+	}
+	ctx := event.C(cooldown)
+	if cooldown.Handler().HandleStart(ctx, dur, val); ctx.Cancelled() {
+		return 0, ErrStartCancelled
+	}
+
+	early, prev := cooldown.RemainingUnsafe(), cooldown.val
+	cooldown.doStopUnsafe(prev)
+	cooldown.expiredAt = cooldown.basic.now()
+	cooldown.Handler().HandleStop(cooldown, ErrStopCauseExpired, prev)
+	if cooldown.ActiveUnsafe() {
+		// Cooldown was started again by HandleStop, the new start replaces
+		// it.
+		cooldown.doStopUnsafe(val)
+	}
+	cooldown.beginUnsafe(dur, val)
+	return early, nil
+}
+
+// ToleranceStats contains statistics of the starts that passed only because
+// of the tolerance.
+type ToleranceStats struct {
+	// Count is the count of such starts.
+	Count int
+	// Total is the sum of the durations cooldown had left on such starts.
+	Total,
+	// Max is the maximum duration cooldown had left on such start.
+	Max time.Duration
+	// Last is the time of the last such start.
+	Last time.Time
+}
+
+// SetTolerance sets the tolerance of the key, e.g. based on the client ping.
+// Zero tolerance removes it.
+func (reg *Registry[K, T]) SetTolerance(key K, tol time.Duration) {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	if tol <= 0 {
+		delete(reg.tolerances, key)
+		return
+	}
+	reg.tolerances[key] = tol
+}
+
+// Tolerance returns the tolerance of the key.
+func (reg *Registry[K, T]) Tolerance(key K) time.Duration {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+	return reg.tolerances[key]
+}
+
+// ReadyWithTolerance returns true if cooldown with provided key is inactive
+// or expires within tolerance of the key.
+func (reg *Registry[K, T]) ReadyWithTolerance(key K) bool {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+	cd, ok := reg.cooldowns[key]
+	return !ok || cd.ReadyWithTolerance(reg.tolerances[key])
+}
+
+// TryStartWithTolerance works like TryStart, but uses tolerance of the key.
+// Starts that passed only because of the tolerance are recorded, see
+// Tolerated.
+func (reg *Registry[K, T]) TryStartWithTolerance(key K, dur time.Duration, val T) error {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+
+	cd, tol := reg.GetUnsafe(key), reg.tolerances[key]
+	cd.mu.Lock()
+	defer cd.mu.Unlock()
+	if !cd.ReadyWithToleranceUnsafe(tol) {
+		remaining := cd.RemainingUnsafe()
+		reg.violateUnsafe(key, remaining)
+		return &ErrOnCooldown{Remaining: remaining}
+	}
+	early, err := cd.startTolerantUnsafe(dur, val)
+	if err != nil {
+		return err
+	}
+	if early > 0 {
+		stats := reg.tolerated[key]
+		stats.Count++
+		stats.Total += early
+		stats.Max = max(stats.Max, early)
+		stats.Last = cd.basic.now()
+		reg.tolerated[key] = stats
+	}
+	return nil
+}
+
+// Tolerated returns statistics of the starts of the key that passed only
+// because of the tolerance.
+func (reg *Registry[K, T]) Tolerated(key K) ToleranceStats {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+	return reg.tolerated[key]
+}
@@ -15,7 +15,46 @@ type Valued[T any] struct {
 
 	basic    *Basic
 	duration time.Duration
-	timer    *time.Timer
+	timer    ClockTimer
+	// gen is incremented on every start, so the start can be identified.
+	gen uint64
+
+	// repeat is the number of cycles of repeating cooldown. It is zero if
+	// cooldown isn't repeating, and negative if it repeats forever.
+	repeat,
+	// iteration is the index of the current cycle.
+	iteration int
+	// origin is the time when the first cycle was started. It is shifted
+	// only by pauses and renews, so cycles don't drift.
+	origin time.Time
+	// val is the value the cooldown was last started with.
+	val T
+
+	// expiredAt is the time when cooldown (or its cycle) expired last time.
+	expiredAt time.Time
+
+	// queueWindow is the duration before expiration when uses are queued.
+	queueWindow time.Duration
+	// queueDepth is the maximum count of queued uses.
+	queueDepth int
+	// queue contains uses executed when cooldown expires.
+	queue []*QueuedUse[T]
+
+	// violation is called with remaining duration on attempts to start the
+	// cooldown while it is active.
+	violation func(early time.Duration)
+	// check is called before every start and rejects it with the returned
+	// error. It is set by the Registry to check its constraints, so owner
+	// (the lock of the registry) must be held while the cooldown is started.
+	check func(val T) error
+	owner *sync.RWMutex
+	// stamp returns timestamp of the pause and resume, if the cooldown is
+	// replicated. pauseStamp is the timestamp of the last of them.
+	stamp      func() Timestamp
+	pauseStamp Timestamp
+	// online is true if the cooldown counts only the time the process is
+	// running, see ValuedOptionOnlineTime.
+	online bool
 
 	handler atomic.Pointer[ValuedHandler[T]]
 }
@@ -60,40 +99,200 @@ func (cooldown *Valued[T]) RenewUnsafe(val T) {
 		return
 	}
 	cooldown.basic.SetUnsafe(dur)
-	timer.Reset(dur)
+	timer.Stop()
+	cooldown.scheduleUnsafe(dur)
+	cooldown.syncOriginUnsafe()
 }
 
 // Start ...
 func (cooldown *Valued[T]) Start(dur time.Duration, val T) bool {
-	cooldown.mu.Lock()
-	defer cooldown.mu.Unlock()
+	defer cooldown.lockStart()()
 	return cooldown.StartUnsafe(dur, val)
 }
 
+// StartUnsafe starts the cooldown. If the cooldown belongs to the Registry,
+// the registry must be locked too, since its constraints are checked.
 func (cooldown *Valued[T]) StartUnsafe(dur time.Duration, val T) bool {
+	return cooldown.startUnsafe(dur, val) == nil
+}
+
+// startUnsafe works like StartUnsafe, but returns the reason the start was
+// rejected: error of the check or ErrStartCancelled.
+func (cooldown *Valued[T]) startUnsafe(dur time.Duration, val T) error {
 	if dur <= 0 {
-		return false
+		return ErrStartCancelled
+	}
+	if cooldown.check != nil {
+		if err := cooldown.check(val); err != nil {
+			return err
+		}
 	}
 	if cooldown.ActiveUnsafe() {
 		cooldown.StopUnsafe(val)
 	}
 	ctx := event.C(cooldown)
 	if cooldown.Handler().HandleStart(ctx, dur, val); ctx.Cancelled() {
-		return false
+		return ErrStartCancelled
+	}
+	cooldown.beginUnsafe(dur, val)
+	return nil
+}
+
+// checkStartUnsafe runs the check and HandleStart for the start of the
+// inactive cooldown, without starting it. Returns the reason the start was
+// rejected, like startUnsafe.
+func (cooldown *Valued[T]) checkStartUnsafe(dur time.Duration, val T) error {
+	if cooldown.check != nil {
+		if err := cooldown.check(val); err != nil {
+			return err
+		}
+	}
+	ctx := event.C(cooldown)
+	if cooldown.Handler().HandleStart(ctx, dur, val); ctx.Cancelled() {
+		return ErrStartCancelled
 	}
-	cooldown.duration = dur
-	cooldown.timer = time.AfterFunc(dur, cooldown.expire)
+	return nil
+}
+
+// beginUnsafe starts the inactive cooldown without calling handlers.
+func (cooldown *Valued[T]) beginUnsafe(dur time.Duration, val T) {
+	cooldown.duration, cooldown.val = dur, val
+	cooldown.scheduleUnsafe(dur)
 	cooldown.basic.SetUnsafe(dur)
-	return true
+	cooldown.repeat, cooldown.iteration = 0, 0
+	cooldown.gen++
 }
 
-func (cooldown *Valued[T]) expire() {
+// start works like Start, but locks only the cooldown and returns the reason
+// the start was rejected. It is used by the Registry, which is already locked.
+func (cooldown *Valued[T]) start(dur time.Duration, val T) error {
 	cooldown.mu.Lock()
 	defer cooldown.mu.Unlock()
+	return cooldown.startUnsafe(dur, val)
+}
+
+// lockStart locks the cooldown to start (or refund) it and returns the
+// function that unlocks it. If the cooldown belongs to the Registry, the
+// registry is locked first, so its constraints can be checked.
+func (cooldown *Valued[T]) lockStart() (unlock func()) {
+	owner := cooldown.owner
+	if owner == nil {
+		cooldown.mu.Lock()
+		return cooldown.mu.Unlock
+	}
+	owner.Lock()
+	cooldown.mu.Lock()
+	return func() {
+		cooldown.mu.Unlock()
+		owner.Unlock()
+	}
+}
+
+// TryStart starts the cooldown only if it is not active, checking and
+// starting it under single lock acquisition. Remaining is the duration until
+// the cooldown expires, whether it was started or not. If start was cancelled
+// by handler, remaining is zero.
+func (cooldown *Valued[T]) TryStart(dur time.Duration, val T) (started bool, remaining time.Duration) {
+	defer cooldown.lockStart()()
+	return cooldown.TryStartUnsafe(dur, val)
+}
+
+func (cooldown *Valued[T]) TryStartUnsafe(dur time.Duration, val T) (started bool, remaining time.Duration) {
+	if cooldown.ActiveUnsafe() {
+		cooldown.violateUnsafe()
+		return false, cooldown.RemainingUnsafe()
+	}
+	if !cooldown.StartUnsafe(dur, val) {
+		return false, 0
+	}
+	return true, cooldown.RemainingUnsafe()
+}
+
+// StartRepeating starts cooldown that automatically re-arms after every
+// expiration, until n cycles are passed. If n is not positive, cooldown
+// repeats until it is stopped.
+//
+// Cycles are scheduled relative to the start time, so they don't drift
+// regardless of how long handlers take. If handler implements RepeatHandler,
+// its HandleCycle is called after every cycle. HandleStop is called only once,
+// after the last cycle, with the value the cooldown was started with.
+func (cooldown *Valued[T]) StartRepeating(dur time.Duration, n int, val T) bool {
+	defer cooldown.lockStart()()
+	return cooldown.StartRepeatingUnsafe(dur, n, val)
+}
 
-	var zeroT T
-	cooldown.Handler().HandleStop(cooldown, ErrStopCauseExpired, zeroT)
-	cooldown.doStopUnsafe(zeroT)
+func (cooldown *Valued[T]) StartRepeatingUnsafe(dur time.Duration, n int, val T) bool {
+	if !cooldown.StartUnsafe(dur, val) {
+		return false
+	}
+	if n <= 0 {
+		n = -1
+	}
+	cooldown.repeat = n
+	cooldown.syncOriginUnsafe()
+	return true
+}
+
+// syncOriginUnsafe recalculates origin of the repeating cooldown from the
+// current expiration.
+func (cooldown *Valued[T]) syncOriginUnsafe() {
+	if cooldown.repeat == 0 {
+		return
+	}
+	cycles := time.Duration(cooldown.iteration + 1)
+	cooldown.origin = cooldown.basic.expiration.Add(-cycles * cooldown.duration)
+}
+
+// scheduleUnsafe creates new expiration timer.
+func (cooldown *Valued[T]) scheduleUnsafe(dur time.Duration) {
+	var timer ClockTimer
+	timer = cooldown.basic.clockOrSystem().AfterFunc(dur, func() {
+		// Handlers and queued uses may start the cooldown again on expiry.
+		defer cooldown.lockStart()()
+		if cooldown.timer != timer {
+			// Timer was stopped or replaced while we were waiting for the lock.
+			return
+		}
+		cooldown.expireUnsafe()
+	})
+	cooldown.timer = timer
+}
+
+func (cooldown *Valued[T]) expireUnsafe() {
+	cooldown.expiredAt = cooldown.basic.now()
+	if cooldown.repeat != 0 {
+		iteration, val := cooldown.iteration, cooldown.val
+		if cooldown.repeat < 0 || iteration+1 < cooldown.repeat {
+			cooldown.iteration++
+			next := cooldown.origin.Add(time.Duration(cooldown.iteration+1) * cooldown.duration)
+			cooldown.basic.ResetUnsafe()
+			cooldown.basic.expiration = next
+			cooldown.scheduleUnsafe(next.Sub(cooldown.basic.now()))
+
+			if h, ok := cooldown.Handler().(RepeatHandler[T]); ok {
+				h.HandleCycle(cooldown, iteration, val)
+			}
+			return
+		}
+		cooldown.doStopUnsafe(val)
+		if h, ok := cooldown.Handler().(RepeatHandler[T]); ok {
+			h.HandleCycle(cooldown, iteration, val)
+		}
+		if cooldown.ActiveUnsafe() {
+			// Cooldown was started again by HandleCycle.
+			return
+		}
+		cooldown.Handler().HandleStop(cooldown, ErrStopCauseExpired, val)
+		cooldown.runQueueUnsafe()
+		return
+	}
+
+	val := cooldown.val
+	// Cooldown is stopped before calling the handler, so it is safe to start
+	// it again from HandleStop.
+	cooldown.doStopUnsafe(val)
+	cooldown.Handler().HandleStop(cooldown, ErrStopCauseExpired, val)
+	cooldown.runQueueUnsafe()
 }
 
 // Stop ...
@@ -109,14 +308,21 @@ func (cooldown *Valued[T]) StopUnsafe(val T) {
 	}
 	cooldown.Handler().HandleStop(cooldown, ErrStopCauseCancelled, val)
 	cooldown.doStopUnsafe(val)
+	cooldown.flushQueueUnsafe()
 }
 
 func (cooldown *Valued[T]) doStopUnsafe(val T) {
 	if cooldown.PausedUnsafe() {
 		cooldown.doResumeUnsafe(val, false) // we will stop the timer
 	}
+	cooldown.resetUnsafe()
+}
+
+// resetUnsafe drops the state of the cooldown without calling handlers.
+func (cooldown *Valued[T]) resetUnsafe() {
 	cooldown.duration = 0
 	cooldown.basic.ResetUnsafe()
+	cooldown.repeat, cooldown.iteration = 0, 0
 
 	if timer := cooldown.timer; timer != nil {
 		timer.Stop()
@@ -146,6 +352,7 @@ func (cooldown *Valued[T]) PauseUnsafe(val T) bool {
 	if !cooldown.basic.PauseUnsafe() {
 		return false
 	}
+	cooldown.stampPauseUnsafe()
 	ok := timer.Stop()
 	cooldown.timer = nil // Resume will create new timer
 	return ok
@@ -159,7 +366,11 @@ func (cooldown *Valued[T]) Resume(val T) bool {
 }
 
 func (cooldown *Valued[T]) ResumeUnsafe(val T) bool {
-	return cooldown.doResumeUnsafe(val, true)
+	if !cooldown.doResumeUnsafe(val, true) {
+		return false
+	}
+	cooldown.stampPauseUnsafe()
+	return true
 }
 
 func (cooldown *Valued[T]) doResumeUnsafe(val T, resetTimer bool) bool {
@@ -174,12 +385,13 @@ func (cooldown *Valued[T]) doResumeUnsafe(val T, resetTimer bool) bool {
 	if cooldown.Handler().HandleResume(ctx, val); ctx.Cancelled() {
 		return false
 	}
-	if !cooldown.basic.ResumeUnsafe() {
+	if !cooldown.basic.resumeShiftedUnsafe() {
 		return false
 	}
 	if resetTimer {
 		// RemainingUnsafe also accounts for paused state
-		cooldown.timer = time.AfterFunc(cooldown.RemainingUnsafe(), cooldown.expire)
+		cooldown.scheduleUnsafe(cooldown.RemainingUnsafe())
+		cooldown.syncOriginUnsafe()
 	}
 	return true
 }
@@ -231,7 +443,11 @@ func (cooldown *Valued[T]) Active() bool {
 }
 
 func (cooldown *Valued[T]) ActiveUnsafe() bool {
-	return cooldown.basic.ActiveUnsafe()
+	if cooldown.basic.ActiveUnsafe() {
+		return true
+	}
+	_, ok := cooldown.pendingCycleUnsafe()
+	return ok
 }
 
 // Remaining ...
@@ -242,9 +458,38 @@ func (cooldown *Valued[T]) Remaining() time.Duration {
 }
 
 func (cooldown *Valued[T]) RemainingUnsafe() time.Duration {
+	if remaining, ok := cooldown.pendingCycleUnsafe(); ok {
+		return remaining
+	}
 	return cooldown.basic.RemainingUnsafe()
 }
 
+// pendingCycleUnsafe returns remaining duration of the cycle the repeating
+// cooldown is in, if the timer re-arming it is running late, so the cooldown
+// stays active between the cycles. Returns false if the cooldown is not
+// between the cycles, or the expired cycle was the last one.
+func (cooldown *Valued[T]) pendingCycleUnsafe() (time.Duration, bool) {
+	if cooldown.repeat == 0 || cooldown.duration <= 0 || cooldown.basic.ActiveUnsafe() {
+		return 0, false
+	}
+	// Cycles are counted from the expiration of the last started one, like
+	// the timer does.
+	late := cooldown.basic.now().Sub(cooldown.basic.expiration)
+	missed := int(late / cooldown.duration)
+	if cooldown.repeat > 0 && cooldown.iteration+1+missed >= cooldown.repeat {
+		return 0, false
+	}
+	return time.Duration(missed+1)*cooldown.duration - late, true
+}
+
+// activeRemaining returns remaining duration and whether cooldown is active
+// under single lock acquisition.
+func (cooldown *Valued[T]) activeRemaining() (time.Duration, bool) {
+	cooldown.mu.RLock()
+	defer cooldown.mu.RUnlock()
+	return cooldown.RemainingUnsafe(), cooldown.ActiveUnsafe()
+}
+
 // Paused ...
 func (cooldown *Valued[T]) Paused() bool {
 	cooldown.mu.RLock()
@@ -256,6 +501,41 @@ func (cooldown *Valued[T]) PausedUnsafe() bool {
 	return cooldown.basic.PausedUnsafe()
 }
 
+// ExpiredAt returns the time when cooldown expired last time. Stopping the
+// cooldown doesn't count as expiration. If cooldown has never expired, zero
+// time.Time is returned.
+func (cooldown *Valued[T]) ExpiredAt() time.Time {
+	cooldown.mu.RLock()
+	defer cooldown.mu.RUnlock()
+	return cooldown.ExpiredAtUnsafe()
+}
+
+func (cooldown *Valued[T]) ExpiredAtUnsafe() time.Time {
+	return cooldown.expiredAt
+}
+
+// Iteration returns index of the current cycle of the repeating cooldown.
+func (cooldown *Valued[T]) Iteration() int {
+	cooldown.mu.RLock()
+	defer cooldown.mu.RUnlock()
+	return cooldown.IterationUnsafe()
+}
+
+func (cooldown *Valued[T]) IterationUnsafe() int {
+	return cooldown.iteration
+}
+
+// Repeating returns true if cooldown was started via StartRepeating.
+func (cooldown *Valued[T]) Repeating() bool {
+	cooldown.mu.RLock()
+	defer cooldown.mu.RUnlock()
+	return cooldown.RepeatingUnsafe()
+}
+
+func (cooldown *Valued[T]) RepeatingUnsafe() bool {
+	return cooldown.repeat != 0
+}
+
 func (cooldown *Valued[T]) L() *sync.RWMutex {
 	return &cooldown.mu
 }
@@ -0,0 +1,172 @@
+package cooldown
+
+import (
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultEarlinessBuckets are the default upper bounds of the earliness
+// histogram of the ViolationRecorder.
+var DefaultEarlinessBuckets = []time.Duration{
+	time.Millisecond * 50,
+	time.Millisecond * 100,
+	time.Millisecond * 250,
+	time.Millisecond * 500,
+	time.Second,
+	time.Second * 5,
+}
+
+// ViolationStats contains violations recorded for the key.
+type ViolationStats struct {
+	// Count is the count of violations, decreased by decay.
+	Count int
+	// Total is the count of all the violations ever recorded.
+	Total int
+	// Histogram contains counts of violations by earliness (how long the
+	// cooldown had left). Histogram[i] counts violations with earliness up to
+	// Buckets[i], and the last element counts the rest.
+	Histogram []int
+	// Last is the time of the last violation.
+	Last time.Time
+}
+
+// ViolationRecorder records attempts to use the cooldown while it is still
+// active, e.g. for anti-cheat. It is opt-in, see ValuedOptionViolations and
+// RegistryOptionViolations.
+type ViolationRecorder[K comparable] struct {
+	mu sync.Mutex
+
+	buckets    []time.Duration
+	thresholds []int
+	decay      time.Duration
+	clock      Clock
+
+	entries map[K]*violationEntry
+
+	handler atomic.Pointer[ViolationHandler[K]]
+}
+
+type violationEntry struct {
+	stats ViolationStats
+	// changed is the time of the last change of the count.
+	changed time.Time
+}
+
+// NewViolationRecorder creates new ViolationRecorder. HandleViolation is
+// called every time count of violations of the key reaches one of provided
+// thresholds. Every decay without violations the count is decreased by one,
+// zero decay disables it.
+func NewViolationRecorder[K comparable](thresholds []int, decay time.Duration, opts ...ViolationOption[K]) *ViolationRecorder[K] {
+	r := &ViolationRecorder[K]{
+		buckets:    DefaultEarlinessBuckets,
+		thresholds: slices.Sorted(slices.Values(thresholds)),
+		decay:      decay,
+		clock:      SystemClock{},
+		entries:    make(map[K]*violationEntry),
+	}
+	for _, opt := range opts {
+		if opt == nil {
+			continue
+		}
+		opt(r)
+	}
+	if r.handler.Load() == nil {
+		h := ViolationHandler[K](NopViolationHandler[K]{})
+		r.handler.Store(&h)
+	}
+	return r
+}
+
+// Record records violation of the key. Early is the duration the cooldown had
+// left.
+func (r *ViolationRecorder[K]) Record(key K, early time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.clock.Now()
+	entry, ok := r.entries[key]
+	if !ok {
+		entry = &violationEntry{stats: ViolationStats{Histogram: make([]int, len(r.buckets)+1)}}
+		r.entries[key] = entry
+	}
+	r.decayUnsafe(entry, now)
+
+	from := entry.stats.Count
+	entry.stats.Count++
+	entry.stats.Total++
+	entry.stats.Last, entry.changed = now, now
+	i, _ := slices.BinarySearch(r.buckets, early)
+	entry.stats.Histogram[i]++
+
+	for _, threshold := range r.thresholds {
+		if from < threshold && entry.stats.Count >= threshold {
+			r.Handler().HandleViolation(r, key, threshold, r.copyStats(entry))
+		}
+	}
+}
+
+// decayUnsafe decreases count of the entry for every decay passed since the
+// last change.
+func (r *ViolationRecorder[K]) decayUnsafe(entry *violationEntry, now time.Time) {
+	if r.decay <= 0 || entry.stats.Count == 0 {
+		return
+	}
+	steps := int(now.Sub(entry.changed) / r.decay)
+	if steps <= 0 {
+		return
+	}
+	entry.stats.Count = max(entry.stats.Count-steps, 0)
+	entry.changed = entry.changed.Add(time.Duration(steps) * r.decay)
+}
+
+func (r *ViolationRecorder[K]) copyStats(entry *violationEntry) ViolationStats {
+	stats := entry.stats
+	stats.Histogram = slices.Clone(stats.Histogram)
+	return stats
+}
+
+// Stats returns violations recorded for the key.
+func (r *ViolationRecorder[K]) Stats(key K) ViolationStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entry, ok := r.entries[key]
+	if !ok {
+		return ViolationStats{Histogram: make([]int, len(r.buckets)+1)}
+	}
+	r.decayUnsafe(entry, r.clock.Now())
+	return r.copyStats(entry)
+}
+
+// Buckets returns upper bounds of the earliness histogram.
+func (r *ViolationRecorder[K]) Buckets() []time.Duration {
+	return slices.Clone(r.buckets)
+}
+
+// Reset forgets violations of the key.
+func (r *ViolationRecorder[K]) Reset(key K) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.entries, key)
+}
+
+// Handler ...
+func (r *ViolationRecorder[K]) Handler() ViolationHandler[K] {
+	return *r.handler.Load()
+}
+
+// Handle ...
+func (r *ViolationRecorder[K]) Handle(handler ViolationHandler[K]) {
+	if handler == nil {
+		handler = NopViolationHandler[K]{}
+	}
+	r.handler.Store(&handler)
+}
+
+// violateUnsafe reports the attempt to start the cooldown while it is active.
+func (cooldown *Valued[T]) violateUnsafe() {
+	if cooldown.violation != nil {
+		cooldown.violation(cooldown.RemainingUnsafe())
+	}
+}
@@ -0,0 +1,64 @@
+package cooldown_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+	"github.com/k4ties/cooldown"
+)
+
+type thresholdHandler struct {
+	cooldown.NopViolationHandler[string]
+	thresholds []int
+}
+
+func (h *thresholdHandler) HandleViolation(_ *cooldown.ViolationRecorder[string], _ string, threshold int, _ cooldown.ViolationStats) {
+	h.thresholds = append(h.thresholds, threshold)
+}
+
+func TestViolationRecorder(t *testing.T) {
+	clock := newFakeClock()
+	h := new(thresholdHandler)
+	r := cooldown.NewViolationRecorder([]int{3, 2}, time.Second,
+		cooldown.ViolationOptionClock[string](clock),
+		cooldown.ViolationOptionHandler[string](h),
+	)
+
+	r.Record("player", time.Millisecond*10)
+	r.Record("player", time.Millisecond*200)
+	r.Record("player", time.Second*10)
+	stats := r.Stats("player")
+	assert.Equal(t, stats.Count, 3)
+	assert.Equal(t, stats.Histogram, []int{1, 0, 1, 0, 0, 0, 1})
+	assert.Equal(t, h.thresholds, []int{2, 3})
+
+	// Counters decay, but total is kept
+	clock.Advance(time.Second * 2)
+	stats = r.Stats("player")
+	assert.Equal(t, stats.Count, 1)
+	assert.Equal(t, stats.Total, 3)
+
+	r.Record("player", 0)
+	assert.Equal(t, h.thresholds, []int{2, 3, 2})
+}
+
+func TestRegistryViolations(t *testing.T) {
+	r := cooldown.NewViolationRecorder[string](nil, 0)
+	reg := cooldown.NewRegistry(cooldown.RegistryOptionViolations[string, struct{}](r))
+
+	assert.Equal(t, reg.TryStart("a", time.Second, struct{}{}), nil)
+	assert.NotEqual(t, reg.TryStart("a", time.Second, struct{}{}), nil)
+	reg.Get("a").TryStart(time.Second, struct{}{})
+	assert.Equal(t, r.Stats("a").Count, 2)
+	assert.Equal(t, r.Stats("b").Count, 0)
+}
+
+func TestValuedViolations(t *testing.T) {
+	r := cooldown.NewViolationRecorder[string](nil, 0)
+	cd := cooldown.NewValued(cooldown.ValuedOptionViolations[string, int](r, "player"))
+
+	cd.TryStart(time.Second, 1)
+	cd.TryStart(time.Second, 1)
+	assert.Equal(t, r.Stats("player").Count, 1)
+}